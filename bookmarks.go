@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Bookmark marks a message, optionally with a note
+type Bookmark struct {
+	Note    string    `json:"note,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Bookmarks is the sidecar store of bookmarks, keyed by session ID and then
+// message UUID. It lives in its own file and never touches Claude's.
+type Bookmarks struct {
+	Sessions map[string]map[string]Bookmark `json:"sessions"`
+	path     string
+	changes  []bookmarkChange // Made since the last save
+}
+
+// bookmarkChange is a bookmark set or, with a nil mark, deleted
+type bookmarkChange struct {
+	session, uuid string
+	mark          *Bookmark
+}
+
+var bookmarkStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("220"))
+
+// DefaultBookmarksPath returns where bookmarks are stored, following the XDG
+// base directory spec: $XDG_DATA_HOME/claude-history-reader/bookmarks.json
+func DefaultBookmarksPath() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "claude-history-reader", "bookmarks.json"), nil
+}
+
+// LoadBookmarks reads the bookmarks at path. A missing file yields an empty
+// store that is created on the first save.
+func LoadBookmarks(path string) (*Bookmarks, error) {
+	b := &Bookmarks{Sessions: make(map[string]map[string]Bookmark), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return b, fmt.Errorf("%s: %w", path, err)
+	}
+	if b.Sessions == nil {
+		b.Sessions = make(map[string]map[string]Bookmark)
+	}
+	return b, nil
+}
+
+// Save writes the changes made since the last save to the file atomically.
+// The file is read again first and only those changes applied to it, so
+// bookmarks saved meanwhile by another viewer are kept.
+func (b *Bookmarks) Save() error {
+	current, err := LoadBookmarks(b.path)
+	if err != nil {
+		return err // Don't overwrite a file we can't read
+	}
+	for _, c := range b.changes {
+		current.apply(c)
+	}
+	b.Sessions, b.changes = current.Sessions, nil
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".bookmarks-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
+
+// Session returns the bookmarks of a session by message UUID. It is nil-safe
+// so exports can run without a store.
+func (b *Bookmarks) Session(session string) map[string]Bookmark {
+	if b == nil {
+		return nil
+	}
+	return b.Sessions[session]
+}
+
+// Get returns the bookmark on a message
+func (b *Bookmarks) Get(session, uuid string) (Bookmark, bool) {
+	mark, ok := b.Session(session)[uuid]
+	return mark, ok
+}
+
+// Set bookmarks a message, keeping the creation time of an existing bookmark
+func (b *Bookmarks) Set(session, uuid, note string) {
+	mark, ok := b.Get(session, uuid)
+	if !ok {
+		mark.Created = time.Now()
+	}
+	mark.Note = note
+	b.change(bookmarkChange{session, uuid, &mark})
+}
+
+// Delete removes the bookmark on a message
+func (b *Bookmarks) Delete(session, uuid string) {
+	b.change(bookmarkChange{session, uuid, nil})
+}
+
+// change applies a change and records it for the next save
+func (b *Bookmarks) change(c bookmarkChange) {
+	b.apply(c)
+	b.changes = append(b.changes, c)
+}
+
+func (b *Bookmarks) apply(c bookmarkChange) {
+	if c.mark == nil {
+		delete(b.Sessions[c.session], c.uuid)
+		if len(b.Sessions[c.session]) == 0 {
+			delete(b.Sessions, c.session)
+		}
+		return
+	}
+	marks := b.Sessions[c.session]
+	if marks == nil {
+		marks = make(map[string]Bookmark)
+		b.Sessions[c.session] = marks
+	}
+	marks[c.uuid] = *c.mark
+}
+
+// sessionID returns the ID bookmarks of a session file are kept under: its
+// file name, which Claude Code sets to the session's UUID
+func sessionID(path string) string {
+	return sessionTitle(path)
+}
+
+// sessionBookmarks returns the bookmarks of the open session
+func (m Model) sessionBookmarks() map[string]Bookmark {
+	return m.bookmarks.Session(sessionID(m.sessionPath))
+}
+
+// bookmarkedMessages returns the indexes of the bookmarked messages in order
+func (m Model) bookmarkedMessages() []int {
+	marks := m.sessionBookmarks()
+	var indexes []int
+	for i := range m.messages {
+		if _, ok := marks[m.messages[i].UUID]; ok && m.messages[i].UUID != "" {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// selectedUUID returns the UUID of the selected message, reporting in the
+// footer when it can't be bookmarked
+func (m *Model) selectedUUID() (string, bool) {
+	if m.bookmarks == nil {
+		m.statusMessage = fmt.Sprintf("Bookmarks are unavailable: %v", m.bookmarksErr)
+		return "", false
+	}
+	if m.msgCursor >= len(m.messages) || m.messages[m.msgCursor].UUID == "" {
+		m.statusMessage = "This message has no UUID to bookmark"
+		return "", false
+	}
+	return m.messages[m.msgCursor].UUID, true
+}
+
+// toggleBookmark bookmarks the selected message, or removes its bookmark
+func (m *Model) toggleBookmark() {
+	uuid, ok := m.selectedUUID()
+	if !ok {
+		return
+	}
+	session := sessionID(m.sessionPath)
+	if _, ok := m.bookmarks.Get(session, uuid); ok {
+		m.bookmarks.Delete(session, uuid)
+		m.statusMessage = "Removed bookmark"
+	} else {
+		m.bookmarks.Set(session, uuid, "")
+		m.statusMessage = "Bookmarked (a: add a note)"
+	}
+	m.saveBookmarks()
+}
+
+// startAnnotation opens the note prompt for the selected message, filled
+// with its current note
+func (m *Model) startAnnotation() {
+	uuid, ok := m.selectedUUID()
+	if !ok {
+		return
+	}
+	mark, _ := m.bookmarks.Get(sessionID(m.sessionPath), uuid)
+	m.searchMode = true
+	m.searchPrompt = "note: "
+	m.searchInput = mark.Note
+}
+
+// annotate sets the note of the selected message, bookmarking it
+func (m *Model) annotate(note string) {
+	uuid, ok := m.selectedUUID()
+	if !ok {
+		return
+	}
+	m.bookmarks.Set(sessionID(m.sessionPath), uuid, strings.TrimSpace(note))
+	m.statusMessage = "Bookmarked with a note"
+	if strings.TrimSpace(note) == "" {
+		m.statusMessage = "Bookmarked"
+	}
+	m.saveBookmarks()
+}
+
+// saveBookmarks writes the store and re-renders the thread with the change
+func (m *Model) saveBookmarks() {
+	if err := m.bookmarks.Save(); err != nil {
+		m.statusMessage = "Saving bookmarks failed: " + err.Error()
+	}
+	m.rerenderAnchored(blockRef{Msg: m.msgCursor, Block: -1})
+}
+
+// moveBookmark scrolls the count-th bookmarked message after (direction > 0)
+// or before the selected one to the top
+func (m *Model) moveBookmark(count, direction int) {
+	marked := m.bookmarkedMessages()
+	if len(marked) == 0 {
+		m.statusMessage = "No bookmarks in this session (m: bookmark)"
+		return
+	}
+	// Position of the selected message among the bookmarks
+	k := sort.SearchInts(marked, m.msgCursor)
+	if direction > 0 && k < len(marked) && marked[k] == m.msgCursor {
+		k++
+	}
+	target := k + (count-1)*direction
+	if direction < 0 {
+		target = k - count
+	}
+	if target < 0 || target >= len(marked) {
+		m.statusMessage = "No more bookmarks"
+		return
+	}
+
+	line := m.nextShownLine(marked[target])
+	m.threadScrollOffset = line
+	if i := m.messageAtLine(line); i >= 0 {
+		m.msgCursor = i
+	}
+	m.handleMessageNavigation(0, 1) // Clamp
+}
+
+// toggleBookmarkList shows the bookmark list in the sidebar, in place of the
+// outline, or hides it
+func (m *Model) toggleBookmarkList() {
+	m.showBookmarks = !m.showBookmarks
+	if m.showBookmarks {
+		m.showOutline = false
+	}
+	m.rerenderAnchored(blockRef{Msg: m.msgCursor, Block: -1})
+}
+
+// bookmarkLine renders the marker added to a bookmarked message's badge line
+func bookmarkLine(mark Bookmark, width int) string {
+	text := "★"
+	if mark.Note != "" {
+		text += " " + firstLine(mark.Note)
+	}
+	return bookmarkStyle.Render(truncateText(text, max(1, width)))
+}
+
+// buildBookmarkPane renders the bookmark list: two rows per bookmark, the
+// one at or before the selected message highlighted
+func (m Model) buildBookmarkPane(width, height int) []string {
+	marked := m.bookmarkedMessages()
+	lines := []string{
+		titleStyle.Render("Bookmarks"),
+		helpStyle.Render(fmt.Sprintf("%d %s", len(marked), plural(len(marked), "bookmark"))),
+		strings.Repeat("─", width),
+	}
+	if len(marked) == 0 {
+		return append(lines, noPreviewStyle.Render("None (m: bookmark)"))
+	}
+
+	current := sort.SearchInts(marked, m.msgCursor+1) - 1
+	rows := max(1, (height-len(lines))/2)
+	start := max(0, min(current-rows/2, len(marked)-rows))
+
+	marks := m.sessionBookmarks()
+	for k := start; k < len(marked) && k < start+rows; k++ {
+		msg := &m.messages[marked[k]]
+		ts := "     "
+		if !msg.Timestamp.IsZero() {
+			ts = msg.Timestamp.Local().Format("15:04")
+		}
+		title := padOrTruncate(truncateText(ts+"  "+msg.Type+"  "+firstLine(msg.PlainText()), width), width)
+		note := truncateText("       "+firstLine(marks[msg.UUID].Note), width)
+
+		switch {
+		case k == current:
+			lines = append(lines, selectedStyle.Render(title))
+		case m.layout.msgStart[marked[k]] < 0:
+			lines = append(lines, helpStyle.Render(title)) // Message hidden
+		default:
+			lines = append(lines, normalStyle.Render(title))
+		}
+		lines = append(lines, bookmarkStyle.Render(note))
+	}
+	return lines
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBookmarksStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "bookmarks.json")
+	b, err := LoadBookmarks(path)
+	if err != nil {
+		t.Fatalf("A missing file should load empty, got %v", err)
+	}
+	b.Set("s1", "u1", "")
+	b.Set("s1", "u1", "root cause")
+	b.Set("s2", "u9", "")
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBookmarks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mark, ok := loaded.Get("s1", "u1"); !ok || mark.Note != "root cause" || mark.Created.IsZero() {
+		t.Errorf("Bookmark not kept: %+v", mark)
+	}
+	loaded.Delete("s2", "u9")
+	if _, ok := loaded.Sessions["s2"]; ok {
+		t.Error("Deleting a session's last bookmark should drop the session")
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBookmarks(path); err == nil {
+		t.Error("A corrupt file should be reported")
+	}
+	if (*Bookmarks)(nil).Session("s1") != nil {
+		t.Error("A nil store should have no bookmarks")
+	}
+}
+
+func TestBookmarksConcurrentViewers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	seed, _ := LoadBookmarks(path)
+	seed.Set("s1", "old", "")
+	if err := seed.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two viewers load the store, then each saves its own change
+	one, _ := LoadBookmarks(path)
+	two, _ := LoadBookmarks(path)
+	one.Set("s1", "u1", "from one")
+	if err := one.Save(); err != nil {
+		t.Fatal(err)
+	}
+	two.Set("s2", "u2", "from two")
+	two.Delete("s1", "old")
+	if err := two.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBookmarks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Get("s1", "u1"); !ok {
+		t.Error("The first viewer's bookmark was lost")
+	}
+	if _, ok := loaded.Get("s2", "u2"); !ok {
+		t.Error("The second viewer's bookmark was lost")
+	}
+	if _, ok := loaded.Get("s1", "old"); ok {
+		t.Error("The deleted bookmark came back")
+	}
+	if _, ok := two.Get("s1", "u1"); !ok {
+		t.Error("Saving should pick up bookmarks saved by other viewers")
+	}
+}
+
+func TestBookmarkNavigation(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	session := filepath.Join(dir, "abc-123.jsonl")
+	var records []string
+	for _, id := range []string{"u1", "a1", "u2", "a2", "u3"} {
+		typ := "user"
+		if id[0] == 'a' {
+			typ = "assistant"
+		}
+		records = append(records, `{"type":"`+typ+`","uuid":"`+id+`","message":{"role":"`+typ+`","content":"message `+id+`"}}`)
+	}
+	if err := os.WriteFile(session, []byte(strings.Join(records, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(nil, "", DefaultConfig(), nil)
+	m.width, m.height, m.ready = 100, 40, true
+	if err := m.openSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	m.msgCursor = 1
+	m.toggleBookmark()
+	m.msgCursor = 3
+	m.startAnnotation()
+	m.annotate("the fix")
+	if got := m.bookmarkedMessages(); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("Bookmarked messages = %v", got)
+	}
+
+	m.msgCursor = 0
+	m.moveBookmark(1, 1)
+	if m.msgCursor != 1 {
+		t.Errorf(") should go to message 1, got %d", m.msgCursor)
+	}
+	m.moveBookmark(1, 1)
+	if m.msgCursor != 3 {
+		t.Errorf(") should go to message 3, got %d", m.msgCursor)
+	}
+	m.moveBookmark(1, -1)
+	if m.msgCursor != 1 {
+		t.Errorf("( should go back to message 1, got %d", m.msgCursor)
+	}
+
+	if view := StripAnsi(m.View()); !strings.Contains(view, "★ the fix") {
+		t.Errorf("Bookmarked message should show its note:\n%s", view)
+	}
+	m.toggleBookmarkList()
+	if view := StripAnsi(m.View()); !strings.Contains(view, "2 bookmarks") {
+		t.Errorf("Bookmark list not shown:\n%s", view)
+	}
+
+	// Stored by session ID, in the sidecar file only
+	data, err := os.ReadFile(filepath.Join(dir, "data", "claude-history-reader", "bookmarks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"abc-123"`) || !strings.Contains(string(data), `"the fix"`) {
+		t.Errorf("Unexpected sidecar file:\n%s", data)
+	}
+
+	m.msgCursor = 1
+	m.toggleBookmark()
+	if got := m.bookmarkedMessages(); len(got) != 1 {
+		t.Errorf("m should remove the bookmark, got %v", got)
+	}
+}
+
+func TestExportsIncludeBookmarks(t *testing.T) {
+	messages := []Message{
+		{Type: "user", UUID: "u-1", Content: []ContentBlock{{Type: "text", Content: "Why did it fail?"}}},
+		{Type: "assistant", UUID: "a-1", Content: []ContentBlock{{Type: "tool_use", Name: "Bash", Content: "has ``` inside"}}},
+	}
+	marks := map[string]Bookmark{"a-1": {Note: "root cause"}}
+
+	var html bytes.Buffer
+	if err := ExportHTML(&html, "session", messages, marks); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<ol id="bookmarks">`, `href="#msg-a-1">root cause`, `class="msg role-assistant marked"`, `<div class="note">root cause</div>`} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("HTML export should contain %q", want)
+		}
+	}
+
+	var md bytes.Buffer
+	if err := ExportMarkdown(&md, "session", messages, marks); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Bookmarks\n\n- [★ root cause](#msg-a-1)", "<a id=\"msg-a-1\"></a>", "## assistant ★", "> **★ Note:** root cause", "````json\nhas ``` inside\n````"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown export should contain %q:\n%s", want, md.String())
+		}
+	}
+}
+
+func TestNotePrompt(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	session := filepath.Join(dir, "abc-123.jsonl")
+	record := `{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}`
+	if err := os.WriteFile(session, []byte(record), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewModel(nil, "", DefaultConfig(), nil)
+	m.width, m.height, m.ready = 100, 40, true
+	if err := m.openSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	m.startAnnotation()
+	var model tea.Model = m
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("naïve")},
+		{Type: tea.KeySpace, Runes: []rune(" ")},
+		{Type: tea.KeyRunes, Runes: []rune("fix 🔧")},
+		{Type: tea.KeyBackspace},
+		{Type: tea.KeyRunes, Runes: []rune("✓")},
+		{Type: tea.KeyEnter},
+	} {
+		model, _ = model.Update(key)
+	}
+	m = model.(Model)
+	if mark, _ := m.bookmarks.Get("abc-123", "u1"); mark.Note != "naïve fix ✓" {
+		t.Errorf("Note = %q, want %q", mark.Note, "naïve fix ✓")
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxOSC52Size is the largest payload sent over OSC 52; terminals commonly
+// drop longer sequences (xterm and tmux cap them around 100 KB)
+const maxOSC52Size = 74994 // Encodes to 99,992 bytes of base64
+
+// terminal is the program's output, shared by Bubble Tea's renderer and OSC
+// 52 copies
+var terminal = newTerminalOutput(os.Stdout)
+
+// clipboardOutput is where OSC 52 sequences are written: the terminal
+var clipboardOutput io.Writer = terminal
+
+// terminalOutput serialises writes to the terminal, so an escape sequence
+// written by a command's goroutine never lands in the middle of a frame. It
+// passes on the file's Read, Close and Fd so Bubble Tea still sees a
+// terminal.
+type terminalOutput struct {
+	mu   sync.Mutex
+	file *os.File
+	w    io.Writer // The file, or a stand-in in tests
+}
+
+func newTerminalOutput(f *os.File) *terminalOutput {
+	return &terminalOutput{file: f, w: f}
+}
+
+func (o *terminalOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Write(p)
+}
+
+func (o *terminalOutput) Read(p []byte) (int, error) { return o.file.Read(p) }
+func (o *terminalOutput) Close() error               { return o.file.Close() }
+func (o *terminalOutput) Fd() uintptr                { return o.file.Fd() }
+
+// clipboardCommands are local clipboard utilities, tried in order; the first
+// one installed also gets the text
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// clipboardMsg reports the result of a copy
+type clipboardMsg struct {
+	label string
+	size  int
+	err   error
+}
+
+// osc52 returns the escape sequence that sets the system clipboard, wrapped
+// for tmux and screen so they pass it on to the outer terminal
+func osc52(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	switch {
+	case os.Getenv("TMUX") != "":
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return "\x1bP" + seq + "\x1b\\"
+	}
+	return seq
+}
+
+// copyToClipboard returns a command that copies text through OSC 52, which
+// works over SSH and in tmux, and through a local clipboard utility when one
+// is installed
+func copyToClipboard(text, label string) tea.Cmd {
+	return func() tea.Msg {
+		sent := false
+		if len(text) <= maxOSC52Size {
+			if _, err := io.WriteString(clipboardOutput, osc52(text)); err == nil {
+				sent = true
+			}
+		}
+		for _, args := range clipboardCommands {
+			if _, err := exec.LookPath(args[0]); err != nil {
+				continue
+			}
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Stdin = strings.NewReader(text)
+			if err := cmd.Run(); err == nil {
+				sent = true
+			}
+			break
+		}
+		if !sent {
+			return clipboardMsg{label: label, err: fmt.Errorf("%s is too large for OSC 52 and no clipboard utility was found", formatSize(len(text)))}
+		}
+		return clipboardMsg{label: label, size: len(text)}
+	}
+}
+
+// handleYankKey handles the key after a "y" prefix, returning the command
+// that copies the chosen text
+func (m *Model) handleYankKey(key string) tea.Cmd {
+	var text, label string
+	var ok bool
+	if m.viewMode == ViewModeJSON {
+		text, label, ok = m.yankJSON(key)
+	} else {
+		text, label, ok = m.yankMessage(key)
+	}
+	if !ok {
+		return nil
+	}
+	if text == "" {
+		m.statusMessage = "Nothing to copy"
+		return nil
+	}
+	return copyToClipboard(text, label)
+}
+
+// yankMessage picks the text to copy in Message mode: the selected message
+// (y), its current block (b), the tool input (i) or key argument (a) of the
+// current or first tool call, the raw record (r) or the session ID (s)
+func (m *Model) yankMessage(key string) (text, label string, ok bool) {
+	if key == "s" {
+		return sessionID(m.sessionPath), "session ID", true
+	}
+	if m.msgCursor >= len(m.messages) {
+		return "", "", false
+	}
+	msg := &m.messages[m.msgCursor]
+
+	switch key {
+	case "y":
+		return msg.PlainText(), "message", true
+	case "b":
+		ref, found := m.currentBlock()
+		if !found {
+			m.statusMessage = "No block to copy"
+			return "", "", false
+		}
+		return msg.Content[ref.Block].Content, "block", true
+	case "i", "a":
+		block, found := m.currentToolUse()
+		if !found {
+			m.statusMessage = "No tool call in this message"
+			return "", "", false
+		}
+		if key == "i" {
+			return block.Content, block.Name + " input", true
+		}
+		if arg, found := keyArgument(block.Content); found {
+			return arg, block.Name + " argument", true
+		}
+		return block.Content, block.Name + " input", true
+	case "r":
+		if m.msgCursor >= len(m.msgRecord) {
+			m.statusMessage = "No raw record for this message"
+			return "", "", false
+		}
+		return m.recordText(m.msgRecord[m.msgCursor]), "raw record", true
+	}
+	return "", "", false
+}
+
+// yankJSON picks the text to copy in JSON mode: the node at the cursor (y),
+// its path (p), its record (r) or the session ID (s)
+func (m *Model) yankJSON(key string) (text, label string, ok bool) {
+	if key == "s" {
+		return sessionID(m.sessionPath), "session ID", true
+	}
+	line := m.originLine(m.cursorLine)
+	r := m.recordAtLine(line)
+	if r < 0 {
+		m.statusMessage = "No record at the cursor"
+		return "", "", false
+	}
+
+	switch key {
+	case "y", "p":
+		n, path := m.jsonRoots[r].nodeAtLine(line)
+		if n == nil {
+			return m.recordText(r), "record", true
+		}
+		if key == "p" {
+			return path, "path", true
+		}
+		if s, isString := n.Value.(string); isString {
+			return s, path, true
+		}
+		data, _ := json.MarshalIndent(n.Value, "", "    ")
+		return string(data), path, true
+	case "r":
+		return m.recordText(r), "record", true
+	}
+	return "", "", false
+}
+
+// recordText returns a record as shown in JSON mode, pretty-printed
+func (m Model) recordText(r int) string {
+	rec := m.records[r]
+	return strings.Join(m.fullRawLines[rec.Start:rec.End], "\n")
+}
+
+// currentToolUse returns the current block when it is a tool call, or else
+// the selected message's first tool call
+func (m Model) currentToolUse() (ContentBlock, bool) {
+	msg := &m.messages[m.msgCursor]
+	if ref, ok := m.currentBlock(); ok && msg.Content[ref.Block].Type == "tool_use" {
+		return msg.Content[ref.Block], true
+	}
+	for _, block := range msg.Content {
+		if block.Type == "tool_use" {
+			return block, true
+		}
+	}
+	return ContentBlock{}, false
+}
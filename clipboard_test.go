@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	if got := osc52("hi"); got != "\x1b]52;c;aGk=\x07" {
+		t.Errorf("osc52() = %q", got)
+	}
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if got := osc52("hi"); got != "\x1bPtmux;\x1b\x1b]52;c;aGk=\x07\x1b\\" {
+		t.Errorf("osc52() in tmux = %q", got)
+	}
+}
+
+// yanked runs a yank command against a captured terminal and returns the
+// copied text
+func yanked(t *testing.T, m *Model, key string) string {
+	t.Helper()
+	var out bytes.Buffer
+	output, commands := clipboardOutput, clipboardCommands
+	clipboardOutput, clipboardCommands = &out, nil
+	t.Cleanup(func() { clipboardOutput, clipboardCommands = output, commands })
+
+	cmd := m.handleYankKey(key)
+	if cmd == nil {
+		t.Fatalf("y%s copied nothing: %s", key, m.statusMessage)
+	}
+	if msg := cmd().(clipboardMsg); msg.err != nil {
+		t.Fatalf("y%s failed: %v", key, msg.err)
+	}
+	seq := strings.TrimSuffix(strings.TrimPrefix(out.String(), "\x1b]52;c;"), "\x07")
+	data, err := base64.StdEncoding.DecodeString(seq)
+	if err != nil {
+		t.Fatalf("Bad OSC 52 sequence %q: %v", out.String(), err)
+	}
+	return string(data)
+}
+
+func TestYank(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm")
+	path := filepath.Join(t.TempDir(), "sess-42.jsonl")
+	data := `{"type":"user","uuid":"u1","message":{"role":"user","content":"run the tests"}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"Running them"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./..."}}]}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := Model{width: 100, height: 30, state: StateViewer, ready: true}
+	if err := m.openSession(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := yanked(t, &m, "y"); got != "run the tests" {
+		t.Errorf("yy = %q", got)
+	}
+	if got := yanked(t, &m, "s"); got != "sess-42" {
+		t.Errorf("ys = %q", got)
+	}
+	m.msgCursor = 1
+	if got := yanked(t, &m, "a"); got != "go test ./..." {
+		t.Errorf("ya = %q", got)
+	}
+	if got := yanked(t, &m, "i"); !strings.Contains(got, `"command": "go test ./..."`) {
+		t.Errorf("yi = %q", got)
+	}
+	if got := yanked(t, &m, "r"); !strings.Contains(got, `"uuid": "a1"`) {
+		t.Errorf("yr = %q", got)
+	}
+	m.msgCursor = 0
+	if m.handleYankKey("i"); m.statusMessage != "No tool call in this message" {
+		t.Errorf("yi without a tool call: %q", m.statusMessage)
+	}
+
+	m.toggleViewMode()
+	m.cursorLine = 0
+	for i, line := range m.rawLines {
+		if strings.Contains(line, `"content": "run the tests"`) {
+			m.cursorLine = i
+		}
+	}
+	if got := yanked(t, &m, "y"); got != "run the tests" {
+		t.Errorf("yy in JSON mode = %q", got)
+	}
+	if got := yanked(t, &m, "p"); got != "$.message.content" {
+		t.Errorf("yp = %q", got)
+	}
+}
+
+// overlapWriter records writes, counting those that start while another is
+// still in progress
+type overlapWriter struct {
+	active, overlaps atomic.Int32
+	mu               sync.Mutex
+	buf              bytes.Buffer
+}
+
+func (w *overlapWriter) Write(p []byte) (int, error) {
+	if w.active.Add(1) > 1 {
+		w.overlaps.Add(1)
+	}
+	defer w.active.Add(-1)
+	time.Sleep(10 * time.Microsecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestClipboardSharesTerminalOutput(t *testing.T) {
+	if clipboardOutput != io.Writer(terminal) {
+		t.Fatal("OSC 52 should be written through the program's output")
+	}
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm")
+	var w overlapWriter
+	out := &terminalOutput{w: &w}
+	output, commands := clipboardOutput, clipboardCommands
+	clipboardOutput, clipboardCommands = out, nil
+	t.Cleanup(func() { clipboardOutput, clipboardCommands = output, commands })
+
+	frame := "\x1b[H" + strings.Repeat("frame line\r\n", 50)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { // The renderer
+		defer wg.Done()
+		for range 100 {
+			io.WriteString(out, frame)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			copyToClipboard("copied", "text")()
+		}
+	}()
+	wg.Wait()
+
+	if n := w.overlaps.Load(); n > 0 {
+		t.Errorf("%d writes overlapped", n)
+	}
+	got := w.buf.String()
+	if n := strings.Count(got, osc52("copied")); n != 100 {
+		t.Errorf("Found %d whole OSC 52 sequences, want 100", n)
+	}
+	if n := strings.Count(got, frame); n != 100 {
+		t.Errorf("Found %d whole frames, want 100", n)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Config holds user settings loaded from the config file
+type Config struct {
+	Redaction RedactionConfig         `json:"redaction"`
+	Keys      KeysConfig              `json:"keys"`
+	Theme     string                  `json:"theme"`  // See themeNames
+	Colors    ColorsConfig            `json:"colors"` // Overrides the theme's colours
+	Defaults  DefaultsConfig          `json:"defaults"`
+	Pricing   map[string]ModelPricing `json:"pricing"` // By model ID prefix
+}
+
+// RedactionConfig controls secret redaction
+type RedactionConfig struct {
+	Viewer   bool               `json:"viewer"`   // Redact in the viewer on startup
+	Exports  bool               `json:"exports"`  // Redact in exports (on unless disabled)
+	Patterns []RedactionPattern `json:"patterns"` // User-defined detectors
+}
+
+// RedactionPattern is a user-defined secret detector. If the regex has a
+// capture group, only the first group is redacted.
+type RedactionPattern struct {
+	Name  string `json:"name"`
+	Regex string `json:"regex"`
+}
+
+// DefaultsConfig sets how sessions open
+type DefaultsConfig struct {
+	ViewMode  string          `json:"view_mode"` // "message" or "json"
+	Collapsed map[string]bool `json:"collapsed"` // Collapse-all toggles, by fold kind
+}
+
+// DefaultConfig returns the settings used when no config file exists
+func DefaultConfig() Config {
+	return Config{
+		Redaction: RedactionConfig{
+			Exports: true,
+		},
+		Theme: "auto",
+		Defaults: DefaultsConfig{
+			ViewMode:  "message",
+			Collapsed: defaultFoldKinds(),
+		},
+		Pricing: DefaultPricing(),
+	}
+}
+
+// ConfigPath returns the path of the config file, following the XDG base
+// directory spec: $XDG_CONFIG_HOME/claude-history-reader/config.json
+func ConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "claude-history-reader", "config.json"), nil
+}
+
+// LoadConfig reads and validates the config file. A missing file is not an
+// error; the defaults are returned instead.
+func LoadConfig() (Config, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return DefaultConfig(), err
+	}
+	return LoadConfigFile(path)
+}
+
+// LoadConfigFile reads and validates the config file at path
+func LoadConfigFile(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	// Unmarshal over the defaults so absent fields keep their default values
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return DefaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the config for mistakes that would otherwise only surface
+// later at runtime
+func (c Config) Validate() error {
+	for i, p := range c.Redaction.Patterns {
+		if p.Name == "" {
+			return fmt.Errorf("redaction.patterns[%d]: name is required", i)
+		}
+		if _, err := regexp.Compile(p.Regex); err != nil {
+			return fmt.Errorf("redaction.patterns[%d] (%s): invalid regex: %w", i, p.Name, err)
+		}
+	}
+	if err := c.Keys.validate(); err != nil {
+		return err
+	}
+	if !themeNames[c.Theme] {
+		return fmt.Errorf("theme: unknown theme %q (expected one of %s)", c.Theme, quotedList(themeNames))
+	}
+	if err := c.Colors.validate(); err != nil {
+		return err
+	}
+	if err := c.Defaults.validate(); err != nil {
+		return err
+	}
+	return validatePricing(c.Pricing)
+}
+
+func (d DefaultsConfig) validate() error {
+	if _, ok := viewModeNames[d.ViewMode]; !ok {
+		return fmt.Errorf(`defaults.view_mode: %q should be "message" or "json"`, d.ViewMode)
+	}
+	for _, kind := range sortedKeys(d.Collapsed) {
+		if !foldKinds[kind] {
+			return fmt.Errorf("defaults.collapsed: unknown block kind %q (expected one of %s)", kind, quotedList(foldKinds))
+		}
+	}
+	return nil
+}
+
+// viewModeNames are the view modes defaults.view_mode accepts
+var viewModeNames = map[string]ViewMode{
+	"message": ViewModeMessage,
+	"json":    ViewModeJSON,
+}
+
+// sortedKeys returns the keys of a map in order, so validation reports the
+// same mistake first every time
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quotedList renders the keys of a set for an error message, e.g.
+// `"a", "b", "c"`
+func quotedList[V any](set map[string]V) string {
+	var b []byte
+	for i, k := range sortedKeys(set) {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmt.Appendf(b, "%q", k)
+	}
+	return string(b)
+}
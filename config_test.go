@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLoadConfigFileMissing(t *testing.T) {
+	cfg, err := LoadConfigFile(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Missing config should not be an error: %v", err)
+	}
+	if !cfg.Redaction.Exports {
+		t.Error("Export redaction should default to on")
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"redaction": {"viewer": true, "patterns": [{"name": "acme", "regex": "ACME-\\d+"}]}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if !cfg.Redaction.Viewer {
+		t.Error("Expected viewer redaction to be enabled")
+	}
+	if !cfg.Redaction.Exports {
+		t.Error("Absent fields should keep their defaults")
+	}
+	if len(cfg.Redaction.Patterns) != 1 || cfg.Redaction.Patterns[0].Name != "acme" {
+		t.Errorf("Unexpected patterns: %+v", cfg.Redaction.Patterns)
+	}
+}
+
+func TestLoadConfigFileInvalidRegex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"redaction": {"patterns": [{"name": "broken", "regex": "("}]}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadConfigFile(path)
+	if err == nil {
+		t.Fatal("Expected an error for an invalid regex")
+	}
+	if !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Error should name the pattern: %v", err)
+	}
+}
+
+func TestLoadConfigFileSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+		"keys": {"viewer": {"ctrl+n": "j", "q": ""}, "file_list": {"x": "q"}},
+		"colors": {"roles": {"user": {"bg": "#336699"}}, "json": {"key": "33"}, "ui": {"border": "60"}},
+		"defaults": {"view_mode": "json", "collapsed": {"thinking": true, "reminder": false}},
+		"pricing": {"my-model": {"input": 1, "output": 2}}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if cfg.Keys.Viewer["ctrl+n"] != "j" || cfg.Keys.FileList["x"] != "q" {
+		t.Errorf("Unexpected keys: %+v", cfg.Keys)
+	}
+	if !cfg.Defaults.Collapsed["thinking"] || cfg.Defaults.Collapsed["reminder"] {
+		t.Errorf("Unexpected collapse defaults: %v", cfg.Defaults.Collapsed)
+	}
+	if _, ok := cfg.Pricing["claude-sonnet-4"]; !ok || cfg.Pricing["my-model"].Output != 2 {
+		t.Errorf("Pricing should add to the list prices: %v", cfg.Pricing)
+	}
+
+	m := NewModel(nil, "", cfg, nil)
+	m.state, m.width, m.height, m.ready = StateViewer, 100, 30, true
+	if !m.startInJSON {
+		t.Error("Sessions should open in JSON mode")
+	}
+	if remapKey(m.keys.Viewer, "ctrl+n") != "j" || remapKey(m.keys.Viewer, "q") != "" || remapKey(m.keys.Viewer, "k") != "k" {
+		t.Error("Viewer keys not remapped")
+	}
+	if kinds := m.startFoldKinds(); !kinds[foldThinking] || kinds[foldReminder] {
+		t.Errorf("Sessions should start with the configured folds: %v", kinds)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"keys": {"viewer": {"x": "jj"}}}`, `keys.viewer["x"]: "jj" is not a built-in key`},
+		{`{"keys": {"file_list": {"x": "t"}}}`, `keys.file_list["x"]: "t" is not a built-in key`},
+		{`{"theme": "solarized"}`, `theme: unknown theme "solarized"`},
+		{`{"colors": {"roles": {"robot": {"fg": "1"}}}}`, `colors.roles: unknown role "robot"`},
+		{`{"colors": {"roles": {"user": {"bg": "blue"}}}}`, `colors.roles.user.bg: "blue" is not a colour`},
+		{`{"colors": {"json": {"key": "256"}}}`, `colors.json.key: "256" is not a colour`},
+		{`{"colors": {"json": {"null": "1"}}}`, `colors.json: unknown token type "null"`},
+		{`{"colors": {"ui": {"background": "1"}}}`, `colors.ui: unknown element "background"`},
+		{`{"defaults": {"view_mode": "tree"}}`, `defaults.view_mode: "tree" should be "message" or "json"`},
+		{`{"defaults": {"collapsed": {"text": true}}}`, `defaults.collapsed: unknown block kind "text"`},
+		{`{"pricing": {"claude-x": {"input": -1}}}`, `pricing["claude-x"].input: -1 is negative`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		_, err := LoadConfigFile(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestRemapLeavesPrefixSecondKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	data := `{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"hello"}]}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.Keys.Viewer = map[string]string{"a": "", "x": "z"}
+	m := NewModel(nil, "", cfg, nil)
+	m.state, m.width, m.height, m.ready = StateViewer, 100, 30, true
+	if err := m.openSession(path); err != nil {
+		t.Fatal(err)
+	}
+	m.selectMessage(1)
+
+	press := func(keys string) {
+		var model tea.Model = m
+		for _, r := range keys {
+			model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		m = model.(Model)
+	}
+	collapsed := m.blockCollapsed(1, 0)
+	press("za")
+	if m.blockCollapsed(1, 0) == collapsed || m.lastKey != "" {
+		t.Errorf("za should toggle the thinking block with a disabled: collapsed %v, pending %q", m.blockCollapsed(1, 0), m.lastKey)
+	}
+	press("xa")
+	if m.blockCollapsed(1, 0) != collapsed {
+		t.Error("A key remapped to z should start a fold command")
+	}
+	press("ga")
+	if m.lastKey != "" {
+		t.Errorf("A disabled key should cancel a pending g, got %q", m.lastKey)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// ContentType is what a piece of free text turns out to be, which decides how
+// it is rendered
+type ContentType int
+
+const (
+	ContentPlain ContentType = iota
+	ContentMarkdown
+	ContentCode // Source code; the language comes with the classification
+	ContentDiff
+	ContentStackTrace
+	ContentANSI   // Terminal output with colour escapes
+	ContentTagged // XML-ish tagged payloads like <command-name> or <system-reminder>
+)
+
+func (t ContentType) String() string {
+	return [...]string{"plain", "markdown", "code", "diff", "stack trace", "ansi", "tagged"}[t]
+}
+
+var (
+	// Line-level markdown constructs. Lone "_", "*" or "[" don't count, so
+	// snake_case names, globs and paths stay plain.
+	markdownPatterns = []*regexp.Regexp{
+		regexp.MustCompile("(?m)^```"),                             // Fenced code
+		regexp.MustCompile(`(?m)^#{1,6} \S`),                       // Headings
+		regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+\.) \S`),           // List items
+		regexp.MustCompile(`(?m)^> \S`),                            // Blockquotes
+		regexp.MustCompile(`(?m)^\|.*\|\s*$`),                      // Table rows
+		regexp.MustCompile(`\*\*[^*\n]+\*\*`),                      // Bold
+		regexp.MustCompile("`[^`\n]+`"),                            // Inline code
+		regexp.MustCompile(`\[[^\]\n]+\]\((?:https?://|/|#)\S*\)`), // Links
+	}
+
+	// Stack traces of common runtimes
+	stackTracePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^Traceback \(most recent call last\):`),      // Python
+		regexp.MustCompile(`(?m)^goroutine \d+ \[`),                          // Go
+		regexp.MustCompile(`(?m)^thread '.*' panicked at`),                   // Rust
+		regexp.MustCompile(`(?m)(?:^\s+at .+\(?.+:\d+(?::\d+)?\)?$\n?){2,}`), // JS, Java
+	}
+
+	// fencedCodePattern matches a fenced code block of Markdown
+	fencedCodePattern = regexp.MustCompile("(?ms)^```.*?^```[ \t]*$")
+
+	// taggedPattern matches text that opens with a lower-case tag that is
+	// closed later on, as Claude Code wraps commands and reminders
+	taggedPattern = regexp.MustCompile(`^<([a-z][a-z0-9_-]*)>`)
+
+	ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+)
+
+// classifyContent decides what text is. For source code it also returns the
+// language.
+func classifyContent(s string) (ContentType, string) {
+	trimmed := strings.TrimSpace(s)
+	switch {
+	case trimmed == "":
+		return ContentPlain, ""
+	case strings.Contains(s, "\x1b["):
+		return ContentANSI, ""
+	}
+	if m := taggedPattern.FindStringSubmatch(trimmed); m != nil && strings.Contains(trimmed, "</"+m[1]+">") {
+		return ContentTagged, ""
+	}
+	lang := sniffLanguage(s)
+	if lang == "diff" {
+		return ContentDiff, ""
+	}
+	unfenced := fencedCodePattern.ReplaceAllString(s, "") // A trace quoted in Markdown stays Markdown
+	for _, p := range stackTracePatterns {
+		if p.MatchString(unfenced) {
+			return ContentStackTrace, ""
+		}
+	}
+	if lang != "" {
+		return ContentCode, lang
+	}
+	for _, p := range markdownPatterns {
+		if p.MatchString(s) {
+			return ContentMarkdown, ""
+		}
+	}
+	return ContentPlain, ""
+}
+
+// renderText renders free text with the renderer for its content type
+func renderText(s string, width int) string {
+	width = max(width, 20)
+	kind, lang := classifyContent(s)
+	switch kind {
+	case ContentMarkdown:
+		return renderMarkdown(s, width)
+	case ContentCode:
+		return highlightCode(s, "", lang)
+	case ContentDiff:
+		return renderDiffText(s)
+	case ContentStackTrace:
+		return renderStackTrace(s)
+	case ContentANSI:
+		return renderANSI(s, width)
+	case ContentTagged:
+		return renderTagged(s, width)
+	default:
+		return wordwrap.String(s, width)
+	}
+}
+
+// renderDiffText colours a unified diff line by line
+func renderDiffText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ "):
+			lines[i] = diffFileStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = diffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = diffRemovedStyle.UnsetBold().Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = diffAddedStyle.UnsetBold().Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stackFramePattern matches the file:line location in a stack frame
+var stackFramePattern = regexp.MustCompile(`[\w./\\-]+\.\w+:\d+(?::\d+)?`)
+
+// renderStackTrace dims the frames of a stack trace, picking out their
+// locations, so the error lines stand out
+func renderStackTrace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		frame := strings.HasPrefix(trimmed, "at ") || strings.HasPrefix(trimmed, "File \"") ||
+			strings.HasPrefix(line, "\t") || strings.HasPrefix(trimmed, "goroutine ")
+		if !frame {
+			lines[i] = warningStyle.UnsetBold().Render(line)
+			continue
+		}
+		loc := stackFramePattern.FindStringIndex(line)
+		if loc == nil {
+			lines[i] = toolNoteStyle.UnsetItalic().Render(line)
+			continue
+		}
+		lines[i] = toolNoteStyle.UnsetItalic().Render(line[:loc[0]]) +
+			toolValueStyle.Render(line[loc[0]:loc[1]]) +
+			toolNoteStyle.UnsetItalic().Render(line[loc[1]:])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderANSI keeps the colours of terminal output, dropping escapes other
+// than colour (cursor movement, clearing) and carriage-return overwrites.
+// Without colour (noColor), colours go too.
+func renderANSI(s string, width int) string {
+	s = ansiEscapePattern.ReplaceAllStringFunc(s, func(seq string) string {
+		if strings.HasSuffix(seq, "m") && !noColor {
+			return seq
+		}
+		return ""
+	})
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if cr := strings.LastIndex(strings.TrimSuffix(line, "\r"), "\r"); cr >= 0 {
+			line = line[cr+1:] // Progress bars redraw over the line
+		}
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return wordwrap.String(strings.Join(lines, "\n"), width) + "\x1b[0m"
+}
+
+// renderTagged renders tagged payloads with their tags dimmed and the text
+// between them rendered for its own content type
+func renderTagged(s string, width int) string {
+	var out []string
+	for _, part := range splitTagged(s) {
+		if part.Tag == "" {
+			if text := strings.TrimSpace(part.Text); text != "" {
+				out = append(out, renderText(text, width))
+			}
+			continue
+		}
+		out = append(out, toolNoteStyle.Render("<"+part.Tag+">"))
+		if text := strings.TrimSpace(part.Text); text != "" {
+			out = append(out, renderText(text, width))
+		}
+		out = append(out, toolNoteStyle.Render("</"+part.Tag+">"))
+	}
+	return strings.Join(out, "\n")
+}
+
+// taggedPart is a run of tagged text: a tag and its contents, or untagged
+// text between tags
+type taggedPart struct {
+	Tag  string // Empty for untagged text
+	Text string
+}
+
+var openTagPattern = regexp.MustCompile(`<([a-z][a-z0-9_-]*)>`)
+
+// splitTagged splits text into top-level tagged elements and the text
+// around them. Tags that are never closed are left in the text.
+func splitTagged(s string) []taggedPart {
+	var parts []taggedPart
+	text := 0 // Start of the untagged text before the next element
+	for pos := 0; pos < len(s); {
+		loc := openTagPattern.FindStringSubmatchIndex(s[pos:])
+		if loc == nil {
+			break
+		}
+		start, body := pos+loc[0], pos+loc[1]
+		tag := s[pos+loc[2] : pos+loc[3]]
+		closeTag := "</" + tag + ">"
+		end := strings.Index(s[body:], closeTag)
+		if end < 0 {
+			pos = body // Unclosed: leave it in the text
+			continue
+		}
+		if start > text {
+			parts = append(parts, taggedPart{Text: s[text:start]})
+		}
+		parts = append(parts, taggedPart{Tag: tag, Text: s[body : body+end]})
+		pos = body + end + len(closeTag)
+		text = pos
+	}
+	if text < len(s) {
+		parts = append(parts, taggedPart{Text: s[text:]})
+	}
+	return parts
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClassifyContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantKind ContentType
+		wantLang string
+	}{
+		{"snake_case is plain", "renamed user_id to account_id in [the] schema", ContentPlain, ""},
+		{"glob is plain", "matched src/*.go and *_test.go", ContentPlain, ""},
+		{"heading", "# Summary\n\nAll done.", ContentMarkdown, ""},
+		{"list", "Changes:\n- one\n- two", ContentMarkdown, ""},
+		{"bold", "This is **important**.", ContentMarkdown, ""},
+		{"inline code", "Run `go test` first.", ContentMarkdown, ""},
+		{"link", "See [the docs](https://example.com).", ContentMarkdown, ""},
+		{"go source", "package main\n\nfunc main() {}\n", ContentCode, "go"},
+		{"json", `{"a": 1}`, ContentCode, "json"},
+		{"diff", "--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-a\n+b", ContentDiff, ""},
+		{"python trace", "Traceback (most recent call last):\n  File \"x.py\", line 1, in <module>\nValueError: bad", ContentStackTrace, ""},
+		{"go panic", "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:5 +0x1d", ContentStackTrace, ""},
+		{"python trace in markdown", "## Error\n\nThe test fails with:\n\n```\nTraceback (most recent call last):\n  File \"x.py\", line 1, in <module>\nValueError: bad\n```\n\n- check the input", ContentMarkdown, ""},
+		{"go panic in a fence", "It crashed:\n```go\ngoroutine 1 [running]:\nmain.main()\n```", ContentMarkdown, ""},
+		{"js trace", "TypeError: x is undefined\n    at f (/app/a.js:3:9)\n    at g (/app/b.js:7:1)", ContentStackTrace, ""},
+		{"ansi", "\x1b[32mok\x1b[0m  pkg 0.1s", ContentANSI, ""},
+		{"tagged", "<command-name>/clear</command-name>\n<command-args></command-args>", ContentTagged, ""},
+		{"unclosed tag", "<b> is bold in HTML", ContentPlain, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, lang := classifyContent(tt.text)
+			if kind != tt.wantKind || lang != tt.wantLang {
+				t.Errorf("classifyContent() = %v, %q, want %v, %q", kind, lang, tt.wantKind, tt.wantLang)
+			}
+		})
+	}
+}
+
+func TestSplitTagged(t *testing.T) {
+	got := splitTagged("before <a>one</a>\n<b>two <c>x</c></b> <open> after")
+	want := []taggedPart{
+		{Text: "before "},
+		{Tag: "a", Text: "one"},
+		{Text: "\n"},
+		{Tag: "b", Text: "two <c>x</c>"},
+		{Text: " <open> after"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitTagged() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	plain := "a_b and c_d stay as they are"
+	if got := renderText(plain, 80); got != plain {
+		t.Errorf("Plain text changed: %q", got)
+	}
+
+	ansi := renderText("\x1b[2K\x1b[31mfail\x1b[0m\n10%\r50%\r100%", 80)
+	if strings.Contains(ansi, "\x1b[2K") || !strings.Contains(ansi, "\x1b[31m") {
+		t.Errorf("Only colour escapes should be kept: %q", ansi)
+	}
+	if got := StripAnsi(ansi); got != "fail\n100%" {
+		t.Errorf("Carriage returns should overwrite the line, got %q", got)
+	}
+
+	tagged := StripAnsi(renderText("<command-name>/clear</command-name>", 80))
+	if tagged != "<command-name>\n/clear\n</command-name>" {
+		t.Errorf("Tagged text rendered as %q", tagged)
+	}
+}
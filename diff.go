@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	diffFileStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("252"))
+
+	diffHunkStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39"))
+
+	diffRemovedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("203")).
+				Bold(true)
+
+	diffAddedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("114")).
+			Bold(true)
+)
+
+// Backgrounds behind removed and added lines. They are raw SGR sequences
+// because they must be re-applied after every reset in highlighted code.
+// Themes without them leave the lines on the terminal's background.
+var (
+	diffRemovedBackground = "\x1b[48;5;52m"
+	diffAddedBackground   = "\x1b[48;5;22m"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// maxDiffCells caps the LCS table size; larger edits are shown as a plain
+// removal followed by an addition
+const maxDiffCells = 4_000_000
+
+// diffOp is one line of a line diff: ' ' unchanged, '-' removed or '+'
+// added. A and B are the positions in the old and new lines; for a removed
+// line B is where it would have been in the new lines, and vice versa.
+type diffOp struct {
+	Kind byte
+	A, B int
+}
+
+// diffLines computes a line diff of a and b from their longest common
+// subsequence
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for i := range a {
+			ops = append(ops, diffOp{Kind: '-', A: i, B: 0})
+		}
+		for j := range b {
+			ops = append(ops, diffOp{Kind: '+', A: len(a), B: j})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{Kind: ' ', A: i, B: j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{Kind: '-', A: i, B: j})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: '+', A: i, B: j})
+			j++
+		}
+	}
+	return ops
+}
+
+// diffHunks groups a diff into hunks of changes with up to context unchanged
+// lines around them
+func diffHunks(ops []diffOp, context int) [][]diffOp {
+	var hunks [][]diffOp
+	start, end := -1, -1 // Current hunk's op range
+	for k, op := range ops {
+		if op.Kind == ' ' {
+			continue
+		}
+		lo := max(k-context, 0)
+		if start >= 0 && lo > end {
+			hunks = append(hunks, ops[start:end])
+			start = -1
+		}
+		if start < 0 {
+			start = lo
+		}
+		end = min(k+context+1, len(ops))
+	}
+	if start >= 0 {
+		hunks = append(hunks, ops[start:end])
+	}
+	return hunks
+}
+
+// splitLines splits text into lines, ignoring one trailing newline
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// cachedFile is a file read to number edit diffs, as of its modification
+// time and size; content is nil if it couldn't be read or is too large
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	content *string
+}
+
+const (
+	maxCachedFiles    = 64
+	maxCachedFileSize = 4 << 20 // Larger files aren't searched for edits
+)
+
+// fileContents caches files read to number edit diffs, so re-rendering
+// doesn't hit the disk more than a stat
+var fileContents = map[string]cachedFile{}
+
+// readFileCached returns a file's contents, or nil if it can't be read or is
+// larger than maxCachedFileSize. Files are read again once they change.
+func readFileCached(path string) *string {
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(fileContents, path)
+		return nil
+	}
+	if cached, ok := fileContents[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.content
+	}
+	var content *string
+	if info.Mode().IsRegular() && info.Size() <= maxCachedFileSize {
+		if data, err := os.ReadFile(path); err == nil {
+			s := string(data)
+			content = &s
+		}
+	}
+	if _, ok := fileContents[path]; !ok && len(fileContents) >= maxCachedFiles {
+		clear(fileContents)
+	}
+	fileContents[path] = cachedFile{modTime: info.ModTime(), size: info.Size(), content: content}
+	return content
+}
+
+// editStartLine finds the 1-based line an edit starts at in the file on
+// disk: where the new text is now or, if the edit was since undone, where the
+// old text still is. ok is false when the file or text can't be found.
+func editStartLine(path, oldString, newString string) (int, bool) {
+	content := readFileCached(path)
+	if content == nil {
+		return 0, false
+	}
+	for _, s := range []string{newString, oldString} {
+		if s == "" {
+			continue
+		}
+		if i := strings.Index(*content, s); i >= 0 {
+			return strings.Count((*content)[:i], "\n") + 1, true
+		}
+	}
+	return 0, false
+}
+
+// fileEdit is one old_string → new_string replacement
+type fileEdit struct {
+	Old, New   string
+	ReplaceAll bool
+}
+
+// renderEditTool renders Edit and MultiEdit calls as unified diffs
+func renderEditTool(in toolInput, width int) (string, bool) {
+	path := in.str("file_path")
+	if path == "" {
+		return "", false
+	}
+
+	var edits []fileEdit
+	if raw, ok := in["edits"].([]interface{}); ok {
+		for _, e := range raw {
+			edit, ok := e.(map[string]interface{})
+			if !ok {
+				return "", false
+			}
+			edits = append(edits, fileEditFrom(toolInput(edit)))
+		}
+	} else {
+		if _, ok := in["old_string"].(string); !ok {
+			return "", false
+		}
+		edits = append(edits, fileEditFrom(in))
+	}
+	if len(edits) == 0 {
+		return "", false
+	}
+
+	lines := []string{
+		diffFileStyle.Render("--- a" + ensureLeadingSlash(path)),
+		diffFileStyle.Render("+++ b" + ensureLeadingSlash(path)),
+	}
+	for i, edit := range edits {
+		if len(edits) > 1 {
+			label := fmt.Sprintf("edit %d/%d", i+1, len(edits))
+			if edit.ReplaceAll {
+				label += " (all occurrences)"
+			}
+			lines = append(lines, toolNoteStyle.Render(label))
+		} else if edit.ReplaceAll {
+			lines = append(lines, toolNoteStyle.Render("all occurrences"))
+		}
+		lines = append(lines, renderEditDiff(path, edit)...)
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func fileEditFrom(in toolInput) fileEdit {
+	return fileEdit{Old: in.str("old_string"), New: in.str("new_string"), ReplaceAll: in.flag("replace_all")}
+}
+
+// ensureLeadingSlash makes "a" + path read like a git diff header
+func ensureLeadingSlash(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+	return "/" + path
+}
+
+// renderEditDiff renders the hunks of one edit, syntax-highlighted by the
+// file's extension. Line numbers are the file's when the edit can be located
+// on disk, and relative to the edit otherwise.
+func renderEditDiff(path string, edit fileEdit) []string {
+	oldLines := splitLines(edit.Old)
+	newLines := splitLines(edit.New)
+	oldCode := splitLines(highlightCode(edit.Old, path, ""))
+	newCode := splitLines(highlightCode(edit.New, path, ""))
+	if len(oldCode) != len(oldLines) {
+		oldCode = oldLines
+	}
+	if len(newCode) != len(newLines) {
+		newCode = newLines
+	}
+
+	start, known := editStartLine(path, edit.Old, edit.New)
+	if !known {
+		start = 1
+	}
+
+	var out []string
+	for _, hunk := range diffHunks(diffLines(oldLines, newLines), diffContext) {
+		oldCount, newCount := 0, 0
+		for _, op := range hunk {
+			if op.Kind != '+' {
+				oldCount++
+			}
+			if op.Kind != '-' {
+				newCount++
+			}
+		}
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(start+hunk[0].A, oldCount), hunkRange(start+hunk[0].B, newCount))
+		if !known {
+			header += toolNoteStyle.Render("  (lines relative to the edit)")
+		}
+		out = append(out, diffHunkStyle.Render(header))
+
+		for _, op := range hunk {
+			gutter := ""
+			if known {
+				n := op.B
+				if op.Kind == '-' {
+					n = op.A
+				}
+				gutter = lineNumberStyle.Render(fmt.Sprint(start+n)) + " "
+			}
+			switch op.Kind {
+			case '-':
+				out = append(out, gutter+withBackground(diffRemovedStyle.Render("-")+oldCode[op.A], diffRemovedBackground))
+			case '+':
+				out = append(out, gutter+withBackground(diffAddedStyle.Render("+")+newCode[op.B], diffAddedBackground))
+			default:
+				out = append(out, gutter+" "+newCode[op.B])
+			}
+		}
+	}
+	return out
+}
+
+// hunkRange formats a unified diff range: "start,count", with start being
+// the line before the range when it is empty
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// withBackground applies an SGR background to a line that may contain its own
+// colours and resets
+func withBackground(line, background string) string {
+	return background + strings.ReplaceAll(line, "\x1b[0m", "\x1b[0m"+background) + "\x1b[0m"
+}
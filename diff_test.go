@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"a", "x", "c", "d", "e"}
+
+	var got strings.Builder
+	for _, op := range diffLines(a, b) {
+		got.WriteByte(op.Kind)
+	}
+	if got.String() != " -+  +" {
+		t.Errorf("diff kinds = %q, want %q", got.String(), " -+  +")
+	}
+}
+
+func TestDiffHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		a = append(a, string(rune('a'+i)))
+	}
+	b = append(b, a...)
+	b[1] = "X"
+	b[18] = "Y"
+
+	hunks := diffHunks(diffLines(a, b), 3)
+	if len(hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(hunks))
+	}
+	// First hunk: 1 line of leading context, -/+, 3 lines of trailing context
+	if len(hunks[0]) != 6 {
+		t.Errorf("first hunk has %d lines, want 6", len(hunks[0]))
+	}
+}
+
+func TestRenderEditTool(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	file := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"bye\")\n}\n"
+	if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := `{"file_path": "` + path + `", "old_string": "func main() {\n\tfmt.Println(\"hi\")\n}", "new_string": "func main() {\n\tfmt.Println(\"bye\")\n}"}`
+	got := StripAnsi(renderToolUse(ContentBlock{Type: "tool_use", Name: "Edit", Content: input}, 80))
+	for _, want := range []string{
+		"--- a" + path,
+		"+++ b" + path,
+		"@@ -5,3 +5,3 @@",
+		`6 -	fmt.Println("hi")`,
+		`6 +	fmt.Println("bye")`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diff lacks %q:\n%s", want, got)
+		}
+	}
+
+	multi := `{"file_path": "/nowhere/x.py", "edits": [{"old_string": "a = 1", "new_string": "a = 2"}, {"old_string": "b", "new_string": "c", "replace_all": true}]}`
+	got = StripAnsi(renderToolUse(ContentBlock{Type: "tool_use", Name: "MultiEdit", Content: multi}, 80))
+	for _, want := range []string{"edit 1/2", "edit 2/2 (all occurrences)", "@@ -1,1 +1,1 @@  (lines relative to the edit)", "-a = 1", "+a = 2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("MultiEdit diff lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestEditStartLineFollowsFileChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	write := func(content string, mtime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	then := time.Now().Add(-time.Hour)
+	write("one\ntwo\nthree\n", then)
+	if line, ok := editStartLine(path, "", "three"); !ok || line != 3 {
+		t.Fatalf("got line %d, %v; want 3", line, ok)
+	}
+	write("zero\none\ntwo\nthree\n", then.Add(time.Second))
+	if line, ok := editStartLine(path, "", "three"); !ok || line != 4 {
+		t.Errorf("A changed file should be read again: got line %d, %v; want 4", line, ok)
+	}
+
+	write(strings.Repeat("x", maxCachedFileSize)+"\nthree\n", then.Add(2*time.Second))
+	if _, ok := editStartLine(path, "", "three"); ok {
+		t.Error("Files over the size limit shouldn't be searched")
+	}
+	if fileContents[path].content != nil {
+		t.Error("Files over the size limit shouldn't be cached")
+	}
+}
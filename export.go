@@ -0,0 +1,599 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// htmlExport is the data handed to the HTML export template
+type htmlExport struct {
+	Title     string
+	Generated string
+	Outline   []htmlOutlineItem
+	Bookmarks []htmlOutlineItem
+	Messages  []htmlMessage
+	CSS       template.CSS
+}
+
+type htmlOutlineItem struct {
+	Anchor string
+	Text   string
+	Time   string
+}
+
+type htmlMessage struct {
+	Anchor string
+	Role   string // CSS class suffix, e.g. "user", "tool-result"
+	Label  string
+	Time   string
+	IsMeta bool
+	Marked bool   // Bookmarked
+	Note   string // Bookmark note
+	Blocks []htmlBlock
+}
+
+type htmlBlock struct {
+	Collapsible bool
+	Class       string
+	Summary     string
+	Body        template.HTML
+}
+
+// ExportHTMLFile parses the session at sessionPath and writes a self-contained
+// HTML export of it to outPath, with the session's bookmarks. Secrets are
+// masked when redactor is non-nil.
+func ExportHTMLFile(sessionPath, outPath string, redactor *Redactor, bookmarks *Bookmarks) error {
+	messages, err := ParseJSONLMessages(sessionPath)
+	if err != nil {
+		return err
+	}
+	messages = redactor.RedactMessages(messages)
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return ExportHTML(f, sessionTitle(sessionPath), messages, bookmarks.Session(sessionID(sessionPath)))
+}
+
+// ExportHTML writes a single-file HTML rendering of messages to w, marking
+// the bookmarked ones (marks is keyed by message UUID and may be nil).
+// All CSS and JS are embedded so the output works offline.
+func ExportHTML(w io.Writer, title string, messages []Message, marks map[string]Bookmark) error {
+	data := htmlExport{
+		Title:     title,
+		Generated: time.Now().Format("2006-01-02 15:04:05"),
+		CSS:       template.CSS(exportRoleCSS()),
+	}
+
+	for i := range messages {
+		msg := &messages[i]
+		anchor := messageAnchor(msg, i)
+
+		hm := htmlMessage{
+			Anchor: anchor,
+			Role:   exportRole(msg),
+			Label:  msg.Type,
+			IsMeta: msg.IsMeta,
+		}
+		if msg.Subtype != "" {
+			hm.Label = fmt.Sprintf("%s (%s)", msg.Type, msg.Subtype)
+		}
+		if msg.HasToolResult() {
+			hm.Label = "user · tool_result"
+		}
+		if !msg.Timestamp.IsZero() {
+			hm.Time = msg.Timestamp.Local().Format("2006-01-02 15:04:05")
+		}
+		for _, block := range msg.Content {
+			hm.Blocks = append(hm.Blocks, exportBlock(block))
+		}
+		if mark, ok := marks[msg.UUID]; ok && msg.UUID != "" {
+			hm.Marked, hm.Note = true, mark.Note
+			text := mark.Note
+			if text == "" {
+				text = truncateText(firstLine(msg.PlainText()), 80)
+			}
+			data.Bookmarks = append(data.Bookmarks, htmlOutlineItem{Anchor: anchor, Text: text, Time: hm.Time})
+		}
+		data.Messages = append(data.Messages, hm)
+
+		if isUserPrompt(msg) {
+			data.Outline = append(data.Outline, htmlOutlineItem{
+				Anchor: anchor,
+				Text:   truncateText(firstLine(msg.PlainText()), 80),
+				Time:   hm.Time,
+			})
+		}
+	}
+
+	return htmlExportTemplate.Execute(w, data)
+}
+
+// sessionTitle derives a display title from a session file path
+func sessionTitle(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// messageAnchor returns the HTML anchor for a message, preferring its UUID
+func messageAnchor(msg *Message, index int) string {
+	if msg.UUID != "" {
+		return "msg-" + msg.UUID
+	}
+	return "msg-" + strconv.Itoa(index)
+}
+
+// exportRole returns the CSS role class used for a message
+func exportRole(msg *Message) string {
+	if msg.HasToolResult() {
+		return "tool-result"
+	}
+	if implementedTypes[msg.Type] {
+		return msg.Type
+	}
+	return "unknown"
+}
+
+// isUserPrompt reports whether msg is something the human actually typed,
+// as opposed to a tool result or injected meta content
+func isUserPrompt(msg *Message) bool {
+	return msg.Type == "user" && !msg.IsMeta && !msg.HasToolResult()
+}
+
+// PlainText returns the message's text content without any styling
+func (m *Message) PlainText() string {
+	var parts []string
+	for _, block := range m.Content {
+		if block.Content != "" {
+			parts = append(parts, block.Content)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func exportBlock(block ContentBlock) htmlBlock {
+	switch block.Type {
+	case "text":
+		return htmlBlock{Class: "text", Body: markdownToHTML(block.Content)}
+
+	case "thinking":
+		return htmlBlock{
+			Collapsible: true,
+			Class:       "thinking",
+			Summary:     "💭 Thinking: " + truncateText(firstLine(block.Content), 80),
+			Body:        markdownToHTML(block.Content),
+		}
+
+	case "tool_use":
+		return htmlBlock{
+			Collapsible: true,
+			Class:       "tool-use",
+			Summary:     "🔧 " + block.Name,
+			Body:        preformattedHTML(block.Content),
+		}
+
+	case "tool_result":
+		lines := strings.Count(block.Content, "\n") + 1
+		return htmlBlock{
+			Collapsible: true,
+			Class:       "tool-result",
+			Summary:     fmt.Sprintf("📤 Result (%d lines)", lines),
+			Body:        preformattedHTML(block.Content),
+		}
+
+	case blockCommand:
+		return htmlBlock{
+			Class: "command",
+			Body:  template.HTML("<code>❯ " + html.EscapeString(block.Content) + "</code>"),
+		}
+
+	case blockCommandOutput:
+		return htmlBlock{
+			Class: "command-output",
+			Body:  template.HTML("<pre>" + html.EscapeString(StripAnsi(block.Content)) + "</pre>"),
+		}
+
+	case blockReminder:
+		return htmlBlock{
+			Collapsible: true,
+			Class:       "reminder",
+			Summary:     "📌 " + reminderLabel(block.Name) + " (injected)",
+			Body:        template.HTML("<pre>" + html.EscapeString(block.Content) + "</pre>"),
+		}
+
+	default:
+		return htmlBlock{
+			Class: "plain",
+			Body:  template.HTML("<pre>" + html.EscapeString(block.Content) + "</pre>"),
+		}
+	}
+}
+
+// preformattedHTML renders content in a <pre>, syntax-highlighting it when it
+// is valid JSON
+func preformattedHTML(content string) template.HTML {
+	if json.Valid([]byte(content)) {
+		return template.HTML(`<pre class="json">` + highlightJSONHTML(content) + "</pre>")
+	}
+	return template.HTML("<pre>" + html.EscapeString(content) + "</pre>")
+}
+
+// highlightJSONHTML is the HTML counterpart of HighlightJSON
+func highlightJSONHTML(content string) string {
+	classes := map[jsonTokenKind]string{
+		jsonTokenKey:    "j-key",
+		jsonTokenString: "j-str",
+		jsonTokenNumber: "j-num",
+		jsonTokenBool:   "j-bool",
+		jsonTokenBrace:  "j-brace",
+	}
+
+	var b strings.Builder
+	for i, line := range strings.Split(content, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, tok := range tokenizeJSONLine(line) {
+			if class, ok := classes[tok.Kind]; ok {
+				fmt.Fprintf(&b, `<span class="%s">%s</span>`, class, html.EscapeString(tok.Text))
+			} else {
+				b.WriteString(html.EscapeString(tok.Text))
+			}
+		}
+	}
+	return b.String()
+}
+
+var exportMarkdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(
+		renderer.WithNodeRenderers(util.Prioritized(escapedHTMLRenderer{}, 100)),
+	),
+)
+
+// markdownToHTML converts markdown to HTML. Raw HTML in the source is shown
+// as escaped text rather than dropped, since session content routinely
+// contains XML-ish tags that are meaningful to the reader.
+func markdownToHTML(content string) template.HTML {
+	var buf bytes.Buffer
+	if err := exportMarkdown.Convert([]byte(content), &buf); err != nil {
+		return template.HTML("<pre>" + html.EscapeString(content) + "</pre>")
+	}
+	return template.HTML(buf.String())
+}
+
+// escapedHTMLRenderer overrides goldmark's raw HTML handling so that inline
+// and block HTML is written out escaped
+type escapedHTMLRenderer struct{}
+
+func (escapedHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindRawHTML, renderEscapedRawHTML)
+	reg.Register(ast.KindHTMLBlock, renderEscapedHTMLBlock)
+}
+
+func renderEscapedRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*ast.RawHTML)
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		_, _ = w.WriteString(html.EscapeString(string(segment.Value(source))))
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func renderEscapedHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.HTMLBlock)
+	if entering {
+		_, _ = w.WriteString("<pre>")
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			_, _ = w.WriteString(html.EscapeString(string(line.Value(source))))
+		}
+		return ast.WalkContinue, nil
+	}
+	if n.HasClosure() {
+		_, _ = w.WriteString(html.EscapeString(string(n.ClosureLine.Value(source))))
+	}
+	_, _ = w.WriteString("</pre>\n")
+	return ast.WalkContinue, nil
+}
+
+// exportRoleCSS generates role and JSON token colours from the terminal
+// styles, so the export matches what the viewer shows
+func exportRoleCSS() string {
+	var b strings.Builder
+
+	roles := []struct {
+		class string
+		style lipgloss.Style
+	}{
+		{"user", userBadgeStyle},
+		{"assistant", assistantBadgeStyle},
+		{"system", systemBadgeStyle},
+		{"summary", summaryBadgeStyle},
+		{"unknown", unknownBadgeStyle},
+		{"tool-result", userBadgeStyle},
+	}
+	for _, r := range roles {
+		fg := cssColor(r.style.GetForeground())
+		bg := cssColor(r.style.GetBackground())
+		fmt.Fprintf(&b, ".role-%s .badge { color: %s; background: %s; }\n", r.class, fg, bg)
+		fmt.Fprintf(&b, ".role-%s { border-left-color: %s; }\n", r.class, bg)
+	}
+	fmt.Fprintf(&b, ".role-tool-result .badge + .badge { background: transparent; color: %s; }\n",
+		cssColor(toolResultHeaderStyle.GetForeground()))
+
+	tokens := []struct {
+		class string
+		style lipgloss.Style
+	}{
+		{"j-key", keyStyle},
+		{"j-str", strStyle},
+		{"j-num", numStyle},
+		{"j-bool", boolStyle},
+		{"j-brace", braceStyle},
+	}
+	for _, t := range tokens {
+		fmt.Fprintf(&b, ".%s { color: %s; }\n", t.class, cssColor(t.style.GetForeground()))
+	}
+
+	return b.String()
+}
+
+// cssColor converts a lipgloss colour into a CSS colour value
+func cssColor(c lipgloss.TerminalColor) string {
+	color, ok := c.(lipgloss.Color)
+	if !ok || color == "" {
+		return "inherit"
+	}
+	if strings.HasPrefix(string(color), "#") {
+		return string(color)
+	}
+	n, err := strconv.Atoi(string(color))
+	if err != nil {
+		return "inherit"
+	}
+	return xterm256Hex(n)
+}
+
+// xterm256Hex returns the hex RGB value of an xterm 256-colour palette index
+func xterm256Hex(n int) string {
+	standard := []string{
+		"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+		"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+	}
+
+	switch {
+	case n < 0 || n > 255:
+		return "inherit"
+	case n < 16:
+		return standard[n]
+	case n < 232:
+		levels := []int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[(n/6)%6], levels[n%6])
+	default:
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// truncateText shortens s to at most max runes, adding an ellipsis if cut
+func truncateText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if max <= 1 {
+		return string(runes[:max])
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+var htmlExportTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+* { box-sizing: border-box; }
+body { margin: 0; font: 15px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; background: #f6f8fa; }
+#sidebar { position: fixed; top: 0; bottom: 0; left: 0; width: 300px; overflow-y: auto; padding: 12px; background: #fff; border-right: 1px solid #d0d7de; }
+#sidebar h1 { font-size: 15px; margin: 0 0 8px; word-break: break-all; }
+#sidebar .generated { color: #656d76; font-size: 12px; margin-bottom: 10px; }
+#search { width: 100%; padding: 6px 8px; border: 1px solid #d0d7de; border-radius: 6px; font-size: 14px; }
+#search-count { color: #656d76; font-size: 12px; margin: 4px 0 10px; min-height: 1em; }
+#outline, #bookmarks { list-style: none; margin: 0; padding: 0; }
+#outline li, #bookmarks li { margin: 0 0 6px; }
+#outline a, #bookmarks a { display: block; padding: 4px 6px; border-radius: 4px; color: #1f2328; text-decoration: none; font-size: 13px; }
+#outline a:hover, #bookmarks a:hover { background: #eaeef2; }
+#outline .time, #bookmarks .time { display: block; color: #656d76; font-size: 11px; }
+main { margin-left: 300px; padding: 16px 24px; max-width: 1100px; }
+.msg { background: #fff; border: 1px solid #d0d7de; border-left: 4px solid #8c959f; border-radius: 6px; margin: 0 0 14px; padding: 10px 14px; }
+.msg.meta { opacity: 0.65; }
+.msg.hidden { display: none; }
+.msg header { display: flex; gap: 8px; align-items: center; margin-bottom: 6px; }
+.badge { font-weight: bold; font-size: 12px; padding: 1px 8px; border-radius: 3px; }
+.msg header .time { color: #656d76; font-size: 12px; margin-left: auto; }
+.msg header a.anchor { color: #8c959f; text-decoration: none; font-size: 12px; }
+pre { background: #f6f8fa; border: 1px solid #d0d7de; border-radius: 4px; padding: 8px; overflow-x: auto; white-space: pre-wrap; word-break: break-word; font: 12.5px/1.45 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+pre.json { background: #1e1e1e; color: #d4d4d4; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 90%; }
+details { margin: 6px 0; }
+details > summary { cursor: pointer; font-weight: 600; font-size: 13px; }
+.block.thinking { color: #656d76; font-style: italic; }
+.block.tool-use > summary { color: #0e7c86; }
+.block.tool-result > summary { color: #bc4c00; }
+.block.command code { color: #8250df; font-weight: bold; }
+.block.reminder { border-left: 3px solid #d4a72c; padding-left: 8px; }
+.block.reminder > summary { color: #9a6700; }
+.msg.marked { box-shadow: 0 0 0 2px #d4a72c; }
+.msg header .bookmark { color: #d4a72c; }
+.note { background: #fff8c5; border: 1px solid #d4a72c; border-radius: 4px; padding: 4px 8px; margin-bottom: 6px; white-space: pre-wrap; }
+#sidebar h2 { font-size: 13px; margin: 12px 0 6px; }
+mark { background: #ffd33d; color: #000; }
+mark.current { background: #fb8f44; }
+{{.CSS}}
+</style>
+</head>
+<body>
+<nav id="sidebar">
+<h1>{{.Title}}</h1>
+<div class="generated">Exported {{.Generated}}</div>
+<input id="search" type="search" placeholder="Search messages…" autocomplete="off">
+<div id="search-count"></div>
+{{- if .Bookmarks}}
+<h2>★ Bookmarks</h2>
+<ol id="bookmarks">
+{{- range .Bookmarks}}
+<li><a href="#{{.Anchor}}">{{.Text}}<span class="time">{{.Time}}</span></a></li>
+{{- end}}
+</ol>
+<h2>Outline</h2>
+{{- end}}
+<ol id="outline">
+{{- range .Outline}}
+<li><a href="#{{.Anchor}}">{{.Text}}<span class="time">{{.Time}}</span></a></li>
+{{- end}}
+</ol>
+</nav>
+<main>
+{{- range .Messages}}
+<article class="msg role-{{.Role}}{{if .IsMeta}} meta{{end}}{{if .Marked}} marked{{end}}" id="{{.Anchor}}">
+<header><span class="badge">{{.Label}}</span>{{if .Marked}}<span class="bookmark">★</span>{{end}}<span class="time">{{.Time}}</span><a class="anchor" href="#{{.Anchor}}">#</a></header>
+{{- if .Note}}
+<div class="note">{{.Note}}</div>
+{{- end}}
+{{- range .Blocks}}
+{{- if .Collapsible}}
+<details class="block {{.Class}}"><summary>{{.Summary}}</summary>{{.Body}}</details>
+{{- else}}
+<div class="block {{.Class}}">{{.Body}}</div>
+{{- end}}
+{{- end}}
+</article>
+{{- end}}
+</main>
+<script>
+(function () {
+  var input = document.getElementById("search");
+  var count = document.getElementById("search-count");
+  var messages = Array.prototype.slice.call(document.querySelectorAll(".msg"));
+  var marks = [];
+  var current = -1;
+  var timer = null;
+
+  function clearMarks() {
+    document.querySelectorAll("mark").forEach(function (m) {
+      var parent = m.parentNode;
+      parent.replaceChild(document.createTextNode(m.textContent), m);
+      parent.normalize();
+    });
+    marks = [];
+    current = -1;
+  }
+
+  function markText(root, query) {
+    var walker = document.createTreeWalker(root, NodeFilter.SHOW_TEXT, null);
+    var nodes = [];
+    while (walker.nextNode()) nodes.push(walker.currentNode);
+    nodes.forEach(function (node) {
+      var text = node.nodeValue;
+      var lower = text.toLowerCase();
+      var idx = lower.indexOf(query);
+      if (idx < 0) return;
+      var frag = document.createDocumentFragment();
+      var pos = 0;
+      while (idx >= 0) {
+        frag.appendChild(document.createTextNode(text.slice(pos, idx)));
+        var mark = document.createElement("mark");
+        mark.textContent = text.slice(idx, idx + query.length);
+        frag.appendChild(mark);
+        marks.push(mark);
+        pos = idx + query.length;
+        idx = lower.indexOf(query, pos);
+      }
+      frag.appendChild(document.createTextNode(text.slice(pos)));
+      node.parentNode.replaceChild(frag, node);
+    });
+  }
+
+  function search() {
+    clearMarks();
+    var query = input.value.trim().toLowerCase();
+    if (!query) {
+      messages.forEach(function (m) { m.classList.remove("hidden"); });
+      count.textContent = "";
+      return;
+    }
+    var shown = 0;
+    messages.forEach(function (m) {
+      var hit = m.textContent.toLowerCase().indexOf(query) >= 0;
+      m.classList.toggle("hidden", !hit);
+      if (!hit) return;
+      shown++;
+      markText(m, query);
+      m.querySelectorAll("details").forEach(function (d) {
+        if (d.querySelector("mark")) d.open = true;
+      });
+    });
+    count.textContent = marks.length + " matches in " + shown + " messages";
+  }
+
+  function next(direction) {
+    if (!marks.length) return;
+    if (current >= 0) marks[current].classList.remove("current");
+    current = (current + direction + marks.length) % marks.length;
+    marks[current].classList.add("current");
+    marks[current].scrollIntoView({ block: "center" });
+  }
+
+  input.addEventListener("input", function () {
+    clearTimeout(timer);
+    timer = setTimeout(search, 150);
+  });
+  input.addEventListener("keydown", function (e) {
+    if (e.key === "Enter") {
+      e.preventDefault();
+      next(e.shiftKey ? -1 : 1);
+    } else if (e.key === "Escape") {
+      input.value = "";
+      search();
+    }
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportHTML(t *testing.T) {
+	messages := []Message{
+		{
+			Type: "user",
+			UUID: "u-1",
+			Content: []ContentBlock{
+				{Type: "text", Content: "Please run the tests <command-name>/test</command-name>"},
+			},
+		},
+		{
+			Type: "assistant",
+			UUID: "a-1",
+			Content: []ContentBlock{
+				{Type: "thinking", Content: "Let me think"},
+				{Type: "tool_use", Name: "Bash", Content: `{"command": "go test"}`},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportHTML(&buf, "session", messages, nil); err != nil {
+		t.Fatalf("ExportHTML failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`id="msg-u-1"`,
+		`id="msg-a-1"`,
+		`href="#msg-u-1"`,
+		`class="msg role-assistant"`,
+		`<details class="block thinking">`,
+		`<span class="j-key">&#34;command&#34;</span>`,
+		`&lt;command-name&gt;`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Export should contain %q", want)
+		}
+	}
+
+	// The export must not reference external assets
+	for _, bad := range []string{"<script src", "<link ", "http://", "https://"} {
+		if strings.Contains(out, bad) {
+			t.Errorf("Export should be self-contained, found %q", bad)
+		}
+	}
+
+	// Raw tags from the session must never reach the page unescaped
+	if strings.Contains(out, "<command-name>") {
+		t.Error("Raw HTML from session content should be escaped")
+	}
+}
+
+func TestXterm256Hex(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{0, "#000000"},
+		{15, "#ffffff"},
+		{27, "#005fff"},
+		{34, "#00af00"},
+		{240, "#585858"},
+	}
+
+	for _, tt := range tests {
+		if got := xterm256Hex(tt.code); got != tt.want {
+			t.Errorf("xterm256Hex(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ExportMarkdownFile parses the session at sessionPath and writes a Markdown
+// export of it to outPath, with the session's bookmarks. Secrets are masked
+// when redactor is non-nil.
+func ExportMarkdownFile(sessionPath, outPath string, redactor *Redactor, bookmarks *Bookmarks) error {
+	messages, err := ParseJSONLMessages(sessionPath)
+	if err != nil {
+		return err
+	}
+	messages = redactor.RedactMessages(messages)
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return ExportMarkdown(f, sessionTitle(sessionPath), messages, bookmarks.Session(sessionID(sessionPath)))
+}
+
+// ExportMarkdown writes messages to w as Markdown: a list of bookmarks, then
+// every message under its own heading, with bookmark notes quoted above its
+// content. marks is keyed by message UUID and may be nil.
+func ExportMarkdown(w io.Writer, title string, messages []Message, marks map[string]Bookmark) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n_Exported %s_\n", title, time.Now().Format("2006-01-02 15:04:05"))
+
+	var listed []string
+	for i := range messages {
+		msg := &messages[i]
+		if mark, ok := marks[msg.UUID]; ok && msg.UUID != "" {
+			text := mark.Note
+			if text == "" {
+				text = truncateText(firstLine(msg.PlainText()), 80)
+			}
+			listed = append(listed, fmt.Sprintf("- [★ %s](#%s)", firstLine(text), messageAnchor(msg, i)))
+		}
+	}
+	if len(listed) > 0 {
+		b.WriteString("\n## Bookmarks\n\n" + strings.Join(listed, "\n") + "\n")
+	}
+
+	for i := range messages {
+		msg := &messages[i]
+		heading := msg.Type
+		if msg.Subtype != "" {
+			heading = fmt.Sprintf("%s (%s)", msg.Type, msg.Subtype)
+		}
+		if msg.HasToolResult() {
+			heading = "user · tool_result"
+		}
+		if !msg.Timestamp.IsZero() {
+			heading += " · " + msg.Timestamp.Local().Format("2006-01-02 15:04:05")
+		}
+		mark, marked := marks[msg.UUID]
+		if marked && msg.UUID != "" {
+			heading += " ★"
+		}
+
+		fmt.Fprintf(&b, "\n---\n\n<a id=\"%s\"></a>\n\n## %s\n", messageAnchor(msg, i), heading)
+		if marked && mark.Note != "" {
+			b.WriteString("\n> **★ Note:** " + strings.ReplaceAll(mark.Note, "\n", "\n> ") + "\n")
+		}
+		for _, block := range msg.Content {
+			if text := markdownBlock(block); text != "" {
+				b.WriteString("\n" + text + "\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownBlock renders a content block as Markdown
+func markdownBlock(block ContentBlock) string {
+	switch block.Type {
+	case "text":
+		return block.Content
+
+	case "thinking":
+		return "<details><summary>💭 Thinking</summary>\n\n" + block.Content + "\n\n</details>"
+
+	case "tool_use":
+		return "**🔧 " + block.Name + "**\n\n" + markdownFence(block.Content, "json")
+
+	case "tool_result":
+		label := "📤 Result"
+		if block.IsError {
+			label = "📤 Error"
+		}
+		return "**" + label + "**\n\n" + markdownFence(block.Content, sniffLanguage(block.Content))
+
+	case blockCommand:
+		return "`❯ " + block.Content + "`"
+
+	case blockCommandOutput:
+		return markdownFence(StripAnsi(block.Content), "")
+
+	case blockReminder:
+		return "<details><summary>📌 " + reminderLabel(block.Name) + " (injected)</summary>\n\n" +
+			markdownFence(block.Content, "") + "\n\n</details>"
+
+	default:
+		return markdownFence(block.Content, "")
+	}
+}
+
+// markdownFence puts content in a fenced code block, with a fence longer than
+// any run of backticks inside it
+func markdownFence(content, lang string) string {
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimRight(content, "\n") + "\n" + fence
+}
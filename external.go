@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// externalDoneMsg reports that the pager or editor exited
+type externalDoneMsg struct {
+	program string
+	err     error
+}
+
+// fileTools are the tools whose file_path can be opened with "gf"
+var fileTools = map[string]bool{
+	"Read": true, "Edit": true, "MultiEdit": true, "Write": true, "NotebookEdit": true,
+}
+
+// pagerCommand returns $PAGER split into its arguments, or less
+func pagerCommand() []string {
+	if args := strings.Fields(os.Getenv("PAGER")); len(args) > 0 {
+		return args
+	}
+	return []string{"less"}
+}
+
+// editorCommand returns $VISUAL or $EDITOR split into its arguments, or vi
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(env)); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{"vi"}
+}
+
+// editorArgs appends the arguments that open path at line to an editor
+// command. Most editors take "+line path"; VS Code and Sublime Text take
+// "path:line".
+func editorArgs(editor []string, path string, line int) []string {
+	args := append([]string(nil), editor...)
+	if line <= 0 {
+		return append(args, path)
+	}
+	switch filepath.Base(editor[0]) {
+	case "code", "codium", "cursor":
+		return append(args, "--goto", path+":"+strconv.Itoa(line))
+	case "subl":
+		return append(args, path+":"+strconv.Itoa(line))
+	}
+	return append(args, "+"+strconv.Itoa(line), path)
+}
+
+// runExternal suspends the program to run args
+func runExternal(args []string) tea.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout // The terminal itself, not the program's shared writer
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return externalDoneMsg{program: filepath.Base(args[0]), err: err}
+	})
+}
+
+// openText writes text to a temporary Markdown file and opens it in the
+// pager or, with edit, the editor. The file is kept until the program exits,
+// since GUI editors like code and subl return before they have read it.
+func (m *Model) openText(text, name string, edit bool) tea.Cmd {
+	f, err := os.CreateTemp("", "claude-"+name+"-*.md")
+	if err != nil {
+		m.statusMessage = "Can't write a temporary file: " + err.Error()
+		return nil
+	}
+	_, err = f.WriteString(text)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		m.statusMessage = "Can't write a temporary file: " + err.Error()
+		return nil
+	}
+
+	m.tempFiles = append(m.tempFiles, f.Name())
+
+	args := pagerCommand()
+	if edit {
+		args = editorCommand()
+	}
+	return runExternal(append(args, f.Name()))
+}
+
+// removeTempFiles removes the files written for the pager or editor
+func (m Model) removeTempFiles() {
+	for _, path := range m.tempFiles {
+		os.Remove(path)
+	}
+}
+
+// openMessageExternally opens the selected message, or just the tool result
+// when that is the current block, in the pager or editor
+func (m *Model) openMessageExternally(edit bool) tea.Cmd {
+	if m.msgCursor >= len(m.messages) {
+		return nil
+	}
+	msg := m.messages[m.msgCursor]
+	name := "message"
+	if ref, ok := m.currentBlock(); ok && msg.Content[ref.Block].Type == "tool_result" {
+		msg.Content = []ContentBlock{msg.Content[ref.Block]}
+		name = "result"
+	}
+
+	var b bytes.Buffer
+	ExportMarkdown(&b, sessionTitle(m.sessionPath), []Message{msg}, m.sessionBookmarks())
+	return m.openText(b.String(), name, edit)
+}
+
+// openSessionExternally opens the whole transcript in the pager
+func (m *Model) openSessionExternally() tea.Cmd {
+	var b bytes.Buffer
+	ExportMarkdown(&b, sessionTitle(m.sessionPath), m.messages, m.sessionBookmarks())
+	return m.openText(b.String(), "session", false)
+}
+
+// openToolFile opens the file of the current tool call or result, or of the
+// selected message's first file tool call or result, in the editor at the
+// line the call touched. The file has to exist within the session's project.
+func (m *Model) openToolFile() tea.Cmd {
+	if m.msgCursor >= len(m.messages) {
+		return nil
+	}
+	msg := &m.messages[m.msgCursor]
+	var path string
+	var line int
+	blocks := msg.Content
+	if ref, ok := m.currentBlock(); ok {
+		blocks = append([]ContentBlock{msg.Content[ref.Block]}, blocks...)
+	}
+	for _, block := range blocks {
+		if block.Type == "tool_use" && fileTools[block.Name] {
+			path, line = toolFilePath(block.Content), toolFileLine(block)
+		} else if block.Type == "tool_result" && fileTools[block.Tool] {
+			path = block.Path
+		}
+		if path != "" {
+			break
+		}
+	}
+	if path == "" {
+		m.statusMessage = "No Read, Edit or Write call in this message"
+		return nil
+	}
+
+	root := m.projectRoot(msg)
+	if !filepath.IsAbs(path) && root != "" {
+		path = filepath.Join(root, path)
+	}
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			m.statusMessage = path + " is outside the project " + root
+			return nil
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		m.statusMessage = "File not found: " + path
+		return nil
+	}
+	return runExternal(editorArgs(editorCommand(), path, line))
+}
+
+// projectRoot returns the project a message was recorded in: its working
+// directory or, failing that, the project being browsed
+func (m Model) projectRoot(msg *Message) string {
+	if cwd, ok := msg.Raw["cwd"].(string); ok && cwd != "" {
+		return cwd
+	}
+	return m.projectPath
+}
+
+// toolFileLine returns the line a file tool call is about: the offset of a
+// Read, or where an edit is in the file now; 0 when unknown
+func toolFileLine(block ContentBlock) int {
+	var in toolInput
+	if json.Unmarshal([]byte(block.Content), &in) != nil {
+		return 0
+	}
+	switch block.Name {
+	case "Read":
+		if offset, ok := in.num("offset"); ok {
+			return int(offset)
+		}
+	case "Edit":
+		if line, ok := editStartLine(in.str("file_path"), in.str("old_string"), in.str("new_string")); ok {
+			return line
+		}
+	case "MultiEdit":
+		if edits, ok := in["edits"].([]interface{}); ok && len(edits) > 0 {
+			if edit, ok := edits[0].(map[string]interface{}); ok {
+				oldString, _ := edit["old_string"].(string)
+				newString, _ := edit["new_string"].(string)
+				if line, ok := editStartLine(in.str("file_path"), oldString, newString); ok {
+					return line
+				}
+			}
+		}
+	}
+	return 0
+}
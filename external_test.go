@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditorArgs(t *testing.T) {
+	tests := []struct {
+		editor []string
+		line   int
+		want   []string
+	}{
+		{[]string{"vim"}, 12, []string{"vim", "+12", "/p/a.go"}},
+		{[]string{"nano"}, 0, []string{"nano", "/p/a.go"}},
+		{[]string{"/usr/bin/code", "-w"}, 3, []string{"/usr/bin/code", "-w", "--goto", "/p/a.go:3"}},
+		{[]string{"subl"}, 7, []string{"subl", "/p/a.go:7"}},
+	}
+	for _, tt := range tests {
+		if got := editorArgs(tt.editor, "/p/a.go", tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("editorArgs(%v, %d) = %v, want %v", tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestOpenToolFile(t *testing.T) {
+	project := t.TempDir()
+	file := filepath.Join(project, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session := filepath.Join(t.TempDir(), "s.jsonl")
+	var records []string
+	for _, input := range []string{
+		`{"file_path":"` + file + `","old_string":"x","new_string":"println(\"hi\")"}`,
+		`{"file_path":"/etc/hosts","old_string":"a","new_string":"b"}`,
+		`{"file_path":"` + filepath.Join(project, "gone.go") + `","old_string":"a","new_string":"b"}`,
+	} {
+		records = append(records, `{"type":"assistant","uuid":"a","cwd":"`+project+`","message":{"role":"assistant","content":[{"type":"tool_use","id":"t","name":"Edit","input":`+input+`}]}}`)
+	}
+	if err := os.WriteFile(session, []byte(strings.Join(records, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := Model{width: 100, height: 30, state: StateViewer, ready: true}
+	if err := m.openSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	if line := toolFileLine(m.messages[0].Content[0]); line != 4 {
+		t.Errorf("Edit should open at line 4, got %d", line)
+	}
+	if cmd := m.openToolFile(); cmd == nil {
+		t.Errorf("gf should open the edited file: %s", m.statusMessage)
+	}
+	m.msgCursor = 1
+	if cmd := m.openToolFile(); cmd != nil || !strings.Contains(m.statusMessage, "outside the project") {
+		t.Errorf("Files outside the project should not open: %q", m.statusMessage)
+	}
+	m.msgCursor = 2
+	if cmd := m.openToolFile(); cmd != nil || !strings.HasPrefix(m.statusMessage, "File not found") {
+		t.Errorf("Missing files should not open: %q", m.statusMessage)
+	}
+}
+
+func TestOpenTextKeepsFileUntilExit(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	m := Model{width: 100, height: 30}
+	m.messages = []Message{{Type: "user", Content: []ContentBlock{{Type: "text", Content: "hello"}}}}
+	m.renderThread(m.threadWidth())
+
+	if cmd := m.openMessageExternally(true); cmd == nil {
+		t.Fatalf("Nothing opened: %s", m.statusMessage)
+	}
+	if len(m.tempFiles) != 1 {
+		t.Fatalf("Temporary files = %v", m.tempFiles)
+	}
+	data, err := os.ReadFile(m.tempFiles[0])
+	if err != nil || !strings.Contains(string(data), "hello") {
+		t.Fatalf("Temporary file should hold the message until exit: %q, %v", data, err)
+	}
+	m.removeTempFiles()
+	if _, err := os.Stat(m.tempFiles[0]); !os.IsNotExist(err) {
+		t.Errorf("Temporary file should be removed on exit, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+// Fold kinds that can be collapsed all at once
+const (
+	foldThinking   = "thinking"
+	foldToolUse    = "tool_use"
+	foldToolResult = "tool_result"
+	foldMeta       = "meta"
+	foldReminder   = "reminder"
+)
+
+// foldKinds are the fold kinds, as named in the config file
+var foldKinds = map[string]bool{
+	foldThinking: true, foldToolUse: true, foldToolResult: true, foldMeta: true, foldReminder: true,
+}
+
+// defaultFoldKinds returns the collapse-all toggles a session starts with
+// unless the config says otherwise: injected reminders are collapsed so the
+// prompts around them stand out
+func defaultFoldKinds() map[string]bool {
+	return map[string]bool{foldReminder: true}
+}
+
+// startFoldKinds returns a copy of the collapse-all toggles sessions start
+// with
+func (m *Model) startFoldKinds() map[string]bool {
+	if m.foldDefaults == nil {
+		return defaultFoldKinds()
+	}
+	kinds := make(map[string]bool, len(m.foldDefaults))
+	for kind, collapsed := range m.foldDefaults {
+		kinds[kind] = collapsed
+	}
+	return kinds
+}
+
+// foldState is the collapse state of one session. Per-block overrides win
+// over the collapse-all toggles of their kind.
+type foldState struct {
+	kinds  map[string]bool   // Collapse-all toggles, by fold kind
+	blocks map[blockRef]bool // Per-block overrides: true collapsed, false expanded
+
+	// JSON mode: nodes at jsonDepth and deeper are folded (-1 folds none),
+	// unless toggled by their start line
+	jsonDepth int
+	jsonNodes map[int]bool
+}
+
+// folds returns the collapse state of the open session, creating it on first
+// use. States are kept for the lifetime of the program, so reopening a
+// session restores its folds.
+func (m *Model) folds() *foldState {
+	if m.foldStates == nil {
+		m.foldStates = make(map[string]*foldState)
+	}
+	fs, ok := m.foldStates[m.sessionPath]
+	if !ok {
+		fs = &foldState{
+			kinds:     m.startFoldKinds(),
+			blocks:    make(map[blockRef]bool),
+			jsonDepth: -1,
+			jsonNodes: make(map[int]bool),
+		}
+		m.foldStates[m.sessionPath] = fs
+	}
+	return fs
+}
+
+// foldKind returns which collapse-all toggle covers a block, or "" if none
+func foldKind(msg *Message, block ContentBlock) string {
+	if msg.IsMeta {
+		return foldMeta
+	}
+	switch block.Type {
+	case "thinking":
+		return foldThinking
+	case "tool_use":
+		return foldToolUse
+	case "tool_result":
+		return foldToolResult
+	case blockReminder:
+		return foldReminder
+	}
+	return ""
+}
+
+// blockCollapsed reports whether block j of message i is collapsed
+func (m *Model) blockCollapsed(i, j int) bool {
+	fs := m.folds()
+	if collapsed, ok := fs.blocks[blockRef{Msg: i, Block: j}]; ok {
+		return collapsed
+	}
+	return fs.kinds[foldKind(&m.messages[i], m.messages[i].Content[j])]
+}
+
+// currentBlock returns the block of the selected message at the top of the
+// screen, or its first block when the message starts further down
+func (m Model) currentBlock() (blockRef, bool) {
+	if m.msgCursor >= len(m.messages) || len(m.messages[m.msgCursor].Content) == 0 {
+		return blockRef{}, false
+	}
+	if m.threadScrollOffset < len(m.layout.lineBlock) {
+		if ref := m.layout.lineBlock[m.threadScrollOffset]; ref.Msg == m.msgCursor && ref.Block >= 0 {
+			return ref, true
+		}
+	}
+	for j, start := range m.layout.blockStart[m.msgCursor] {
+		if start >= 0 {
+			return blockRef{Msg: m.msgCursor, Block: j}, true
+		}
+	}
+	return blockRef{}, false
+}
+
+// handleFoldKey handles the key after a "z" prefix
+func (m *Model) handleFoldKey(key string) {
+	fs := m.folds()
+	anchor := blockRef{Msg: m.msgCursor, Block: -1}
+
+	switch key {
+	case "a": // Toggle the current block
+		ref, ok := m.currentBlock()
+		if !ok {
+			return
+		}
+		fs.blocks[ref] = !m.blockCollapsed(ref.Msg, ref.Block)
+		anchor = ref
+
+	case "A": // Toggle every block of the selected message
+		if m.msgCursor >= len(m.messages) {
+			return
+		}
+		collapse := false
+		for j := range m.messages[m.msgCursor].Content {
+			if !m.blockCollapsed(m.msgCursor, j) {
+				collapse = true
+			}
+		}
+		for j := range m.messages[m.msgCursor].Content {
+			fs.blocks[blockRef{Msg: m.msgCursor, Block: j}] = collapse
+		}
+
+	case "t":
+		m.toggleFoldKind(foldThinking, "thinking")
+	case "u":
+		m.toggleFoldKind(foldToolUse, "tool calls")
+	case "o":
+		m.toggleFoldKind(foldToolResult, "tool results")
+	case "m":
+		m.toggleFoldKind(foldMeta, "meta messages")
+	case "r":
+		m.toggleFoldKind(foldReminder, "reminders")
+
+	case "R": // Reset to the defaults
+		fs.kinds = m.startFoldKinds()
+		fs.blocks = make(map[blockRef]bool)
+		m.statusMessage = "Folds reset"
+
+	default:
+		return
+	}
+
+	m.rerenderAnchored(anchor)
+}
+
+// toggleFoldKind collapses or expands every block of a kind, dropping
+// per-block overrides so the whole kind ends up in the same state
+func (m *Model) toggleFoldKind(kind, label string) {
+	fs := m.folds()
+	fs.kinds[kind] = !fs.kinds[kind]
+	for ref := range fs.blocks {
+		if ref.Msg < len(m.messages) && ref.Block < len(m.messages[ref.Msg].Content) &&
+			foldKind(&m.messages[ref.Msg], m.messages[ref.Msg].Content[ref.Block]) == kind {
+			delete(fs.blocks, ref)
+		}
+	}
+	if fs.kinds[kind] {
+		m.statusMessage = "Collapsed all " + label
+	} else {
+		m.statusMessage = "Expanded all " + label
+	}
+}
+
+// rerenderAnchored re-renders the thread keeping a block (or, for Block -1,
+// the start of a message) on the same screen row. If the anchor's message is
+// no longer shown, the next shown message goes to the top.
+func (m *Model) rerenderAnchored(anchor blockRef) {
+	row := 0
+	if line, ok := m.anchorLine(anchor); ok && line > m.threadScrollOffset {
+		row = line - m.threadScrollOffset
+	}
+
+	m.renderThread(m.threadWidth())
+	if line, ok := m.anchorLine(anchor); ok {
+		m.threadScrollOffset = line - row
+	} else {
+		m.threadScrollOffset = m.nextShownLine(anchor.Msg)
+		if i := m.messageAtLine(m.threadScrollOffset); i >= 0 && anchor.Msg == m.msgCursor {
+			m.msgCursor = i
+		}
+	}
+	m.handleMessageNavigation(0, 1) // Clamp, and move the cursor off hidden messages
+	m.refreshSearch()
+}
+
+// nextShownLine returns the first line of the first shown message at or after
+// message i, or of the last shown message before it
+func (m Model) nextShownLine(i int) int {
+	for j := i; j < len(m.layout.msgStart); j++ {
+		if m.layout.msgStart[j] >= 0 {
+			return m.layout.msgStart[j]
+		}
+	}
+	for j := i - 1; j >= 0 && j < len(m.layout.msgStart); j-- {
+		if m.layout.msgStart[j] >= 0 {
+			return m.layout.msgStart[j]
+		}
+	}
+	return 0
+}
+
+// anchorLine returns the rendered line of a block or message start
+func (m Model) anchorLine(ref blockRef) (int, bool) {
+	if ref.Msg < 0 || ref.Msg >= len(m.layout.msgStart) || m.layout.msgStart[ref.Msg] < 0 {
+		return 0, false
+	}
+	if ref.Block < 0 {
+		return m.layout.msgStart[ref.Msg], true
+	}
+	line := m.layout.blockStart[ref.Msg][ref.Block]
+	return line, line >= 0
+}
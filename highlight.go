@@ -14,24 +14,33 @@ var (
 	numStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("141")) // Purple for numbers
 	boolStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("208")) // Orange for booleans/null
 	braceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245")) // Gray for braces/brackets
-
-	// Search highlight style
-	searchHighlightStyle = lipgloss.NewStyle().
-		Background(lipgloss.Color("226")).
-		Foreground(lipgloss.Color("0")).
-		Bold(true)
 )
 
-// HighlightJSON applies syntax highlighting to pretty-printed JSON
+// HighlightJSON applies syntax highlighting to pretty-printed JSON records
+// separated by blank lines, styling each token by its node in the record's
+// tree
 func HighlightJSON(content string) string {
 	lines := strings.Split(content, "\n")
-	var result []string
+	records := splitRecords(lines)
+	return strings.Join(renderJSONLines(lines, records, buildJSONTrees(records)), "\n")
+}
 
-	for _, line := range lines {
-		result = append(result, highlightJSONLine(line))
-	}
+// jsonTokenKind classifies a lexed piece of a pretty-printed JSON line
+type jsonTokenKind int
 
-	return strings.Join(result, "\n")
+const (
+	jsonTokenPlain jsonTokenKind = iota // Whitespace, colons, commas, anything unrecognised
+	jsonTokenKey
+	jsonTokenString
+	jsonTokenNumber
+	jsonTokenBool // Booleans and null
+	jsonTokenBrace
+)
+
+// jsonToken is a run of text on a JSON line with its kind
+type jsonToken struct {
+	Kind jsonTokenKind
+	Text string
 }
 
 // highlightJSONLine applies syntax highlighting to a single line of JSON
@@ -41,26 +50,49 @@ func highlightJSONLine(line string) string {
 	}
 
 	var result strings.Builder
+	for _, tok := range tokenizeJSONLine(line) {
+		switch tok.Kind {
+		case jsonTokenKey:
+			result.WriteString(keyStyle.Render(tok.Text))
+		case jsonTokenString:
+			result.WriteString(strStyle.Render(tok.Text))
+		case jsonTokenNumber:
+			result.WriteString(numStyle.Render(tok.Text))
+		case jsonTokenBool:
+			result.WriteString(boolStyle.Render(tok.Text))
+		case jsonTokenBrace:
+			result.WriteString(braceStyle.Render(tok.Text))
+		default:
+			result.WriteString(tok.Text)
+		}
+	}
+
+	return result.String()
+}
+
+// tokenizeJSONLine splits a single line of pretty-printed JSON into tokens.
+// Concatenating the token texts reproduces the line exactly.
+func tokenizeJSONLine(line string) []jsonToken {
+	var tokens []jsonToken
 	i := 0
 	n := len(line)
 
+	emit := func(kind jsonTokenKind, text string) {
+		// Merge adjacent plain runs so callers see fewer tokens
+		if kind == jsonTokenPlain && len(tokens) > 0 && tokens[len(tokens)-1].Kind == jsonTokenPlain {
+			tokens[len(tokens)-1].Text += text
+			return
+		}
+		tokens = append(tokens, jsonToken{Kind: kind, Text: text})
+	}
+
 	for i < n {
 		ch := line[i]
 
 		switch {
-		// Handle whitespace (preserve indentation)
-		case ch == ' ' || ch == '\t':
-			result.WriteByte(ch)
-			i++
-
 		// Handle braces and brackets
 		case ch == '{' || ch == '}' || ch == '[' || ch == ']':
-			result.WriteString(braceStyle.Render(string(ch)))
-			i++
-
-		// Handle colons and commas
-		case ch == ':' || ch == ',':
-			result.WriteByte(ch)
+			emit(jsonTokenBrace, string(ch))
 			i++
 
 		// Handle strings (keys or values)
@@ -78,14 +110,12 @@ func highlightJSONLine(line string) string {
 				j++ // Include closing quote
 			}
 
-			str := line[i:j]
-
 			// Check if this is a key (followed by colon)
 			restTrimmed := strings.TrimSpace(line[j:])
 			if len(restTrimmed) > 0 && restTrimmed[0] == ':' {
-				result.WriteString(keyStyle.Render(str))
+				emit(jsonTokenKey, line[i:j])
 			} else {
-				result.WriteString(strStyle.Render(str))
+				emit(jsonTokenString, line[i:j])
 			}
 			i = j
 
@@ -97,78 +127,102 @@ func highlightJSONLine(line string) string {
 				(line[j] >= '0' && line[j] <= '9')) {
 				j++
 			}
-			result.WriteString(numStyle.Render(line[i:j]))
+			emit(jsonTokenNumber, line[i:j])
 			i = j
 
 		// Handle booleans and null
 		case ch == 't' || ch == 'f' || ch == 'n':
-			// Check for true, false, null
 			remaining := line[i:]
-			if strings.HasPrefix(remaining, "true") {
-				result.WriteString(boolStyle.Render("true"))
-				i += 4
-			} else if strings.HasPrefix(remaining, "false") {
-				result.WriteString(boolStyle.Render("false"))
-				i += 5
-			} else if strings.HasPrefix(remaining, "null") {
-				result.WriteString(boolStyle.Render("null"))
-				i += 4
-			} else {
-				result.WriteByte(ch)
+			matched := false
+			for _, word := range []string{"true", "false", "null"} {
+				if strings.HasPrefix(remaining, word) {
+					emit(jsonTokenBool, word)
+					i += len(word)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				emit(jsonTokenPlain, string(ch))
 				i++
 			}
 
+		// Whitespace (indentation), colons, commas and anything else
 		default:
-			result.WriteByte(ch)
+			emit(jsonTokenPlain, string(ch))
 			i++
 		}
 	}
 
-	return result.String()
+	return tokens
+}
+
+// Search highlight colours. Highlights are raw escape sequences because they
+// are spliced into already-styled lines; setting the colours (palette.go)
+// rebuilds searchHighlightStart.
+var (
+	searchMatchText       = "0"   // Black
+	searchMatchBackground = "226" // On yellow
+	searchMatchReverse    = false // Reverse video, for themes without colour
+
+	// searchHighlightStart is the escape sequence that opens a search highlight
+	searchHighlightStart = searchHighlightSGR()
+)
+
+// searchHighlightSGR returns the escape sequence for the search highlight
+// colours: always bold, so matches stand out even without colour
+func searchHighlightSGR() string {
+	params := []string{"1"}
+	if searchMatchReverse {
+		params = append(params, "7")
+	}
+	for _, p := range []string{sgrColor(searchMatchText, false), sgrColor(searchMatchBackground, true)} {
+		if p != "" {
+			params = append(params, p)
+		}
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
 }
 
-// HighlightSearch highlights all occurrences of query in the content
+// HighlightSearch highlights all occurrences of query in the content using
+// the default (smart-case, literal) search options
 // This works on already syntax-highlighted content by operating on visible text
 func HighlightSearch(content string, query string) string {
 	if query == "" {
 		return content
 	}
 
-	// Use case-insensitive matching
-	queryLower := strings.ToLower(query)
+	pattern, err := CompileSearch(query, SearchOptions{})
+	if err != nil {
+		return content
+	}
+	return HighlightPattern(content, pattern)
+}
+
+// HighlightPattern highlights all matches of pattern in the content
+func HighlightPattern(content string, pattern *SearchPattern) string {
+	if pattern == nil {
+		return content
+	}
+
 	lines := strings.Split(content, "\n")
 	var result []string
 
 	for _, line := range lines {
-		result = append(result, highlightSearchInLine(line, queryLower))
+		result = append(result, highlightSearchInLine(line, pattern))
 	}
 
 	return strings.Join(result, "\n")
 }
 
 // highlightSearchInLine highlights search matches in a single line
-// Handles ANSI escape codes by tracking visible character positions
-func highlightSearchInLine(line string, queryLower string) string {
-	if queryLower == "" {
-		return line
-	}
-
-	// Extract visible text
+// Handles ANSI escape codes by tracking visible character positions. Styles
+// that were active when a match started are restored after it ends, and
+// styles that change inside a match are deferred until the match is over.
+func highlightSearchInLine(line string, pattern *SearchPattern) string {
+	// Match against the visible text
 	visible, _ := extractVisibleText(line)
-	visibleLower := strings.ToLower(visible)
-
-	// Find all match positions in visible text
-	var matches []int
-	pos := 0
-	for {
-		idx := strings.Index(visibleLower[pos:], queryLower)
-		if idx == -1 {
-			break
-		}
-		matches = append(matches, pos+idx)
-		pos = pos + idx + 1
-	}
-
+	matches := pattern.FindAll(visible)
 	if len(matches) == 0 {
 		return line
 	}
@@ -179,21 +233,27 @@ func highlightSearchInLine(line string, queryLower string) string {
 	visibleIdx := 0
 	matchIdx := 0
 	inMatch := false
-	matchEnd := 0
+	activeStyle := "" // SGR sequences in effect since the last reset
 
 	for lineIdx < len(line) {
 		// Check if we're at an ANSI escape sequence
 		if ansiSeq := getAnsiSequence(line[lineIdx:]); ansiSeq != "" {
-			result.WriteString(ansiSeq)
+			if ansiSeq == "\x1b[0m" || ansiSeq == "\x1b[m" {
+				activeStyle = ""
+			} else {
+				activeStyle += ansiSeq
+			}
+			if !inMatch {
+				result.WriteString(ansiSeq)
+			}
 			lineIdx += len(ansiSeq)
 			continue
 		}
 
 		// Check if we're starting a new match
-		if matchIdx < len(matches) && visibleIdx == matches[matchIdx] {
+		if !inMatch && matchIdx < len(matches) && visibleIdx == matches[matchIdx][0] {
 			inMatch = true
-			matchEnd = visibleIdx + len(queryLower)
-			result.WriteString("\x1b[1;30;48;5;226m") // Bold black on yellow
+			result.WriteString(searchHighlightStart)
 		}
 
 		// Write the character
@@ -202,14 +262,17 @@ func highlightSearchInLine(line string, queryLower string) string {
 		visibleIdx++
 
 		// Check if we're ending a match
-		if inMatch && visibleIdx == matchEnd {
-			result.WriteString("\x1b[0m") // Reset
-			// Re-apply any style that was active (simplified: just reset)
+		if inMatch && visibleIdx == matches[matchIdx][1] {
+			result.WriteString("\x1b[0m" + activeStyle)
 			inMatch = false
 			matchIdx++
 		}
 	}
 
+	if inMatch {
+		result.WriteString("\x1b[0m")
+	}
+
 	return result.String()
 }
 
@@ -255,8 +318,9 @@ func getAnsiSequence(s string) string {
 	return ""
 }
 
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
+
 // StripAnsi removes ANSI escape codes from a string
 func StripAnsi(s string) string {
-	ansiRegex := regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
 	return ansiRegex.ReplaceAllString(s, "")
 }
@@ -74,3 +74,25 @@ func TestStripAnsi(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, result)
 	}
 }
+
+func TestHighlightPatternAcrossAnsi(t *testing.T) {
+	// "foo" is split across two differently-styled runs
+	input := "\x1b[31mfo\x1b[0m\x1b[32mo bar\x1b[0m"
+	pattern, err := CompileSearch("fo+", SearchOptions{Regex: true})
+	if err != nil {
+		t.Fatalf("CompileSearch failed: %v", err)
+	}
+
+	result := HighlightPattern(input, pattern)
+
+	if StripAnsi(result) != "foo bar" {
+		t.Errorf("Visible text changed: %q", StripAnsi(result))
+	}
+	if strings.Count(result, searchHighlightStart) != 1 {
+		t.Errorf("Expected a single highlight spanning the styled runs, got %q", result)
+	}
+	// The green style that began inside the match must be restored after it
+	if !strings.Contains(result, "\x1b[0m\x1b[32m bar") {
+		t.Errorf("Style active at the end of the match was not restored: %q", result)
+	}
+}
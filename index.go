@@ -0,0 +1,417 @@
+package main
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// indexVersion is bumped whenever the on-disk format or tokenization
+// changes, forcing a full rebuild
+const indexVersion = 2 // 2: text is redacted before it is stored
+
+// maxSnippetText caps the text stored per document for snippets. Terms are
+// indexed over the full text.
+const maxSnippetText = 4000
+
+// Index is an inverted index over every session under ~/.claude/projects.
+// It is kept per file so that changed files can be re-indexed on their own.
+type Index struct {
+	Version  int
+	Redactor string                  // Fingerprint of the redactor the text was masked with
+	Files    map[string]*IndexedFile // Keyed by session path
+}
+
+// IndexedFile is the index of a single session file
+type IndexedFile struct {
+	Size    int64
+	ModTime time.Time
+	Project string // Claude project directory name
+	Docs    []IndexDoc
+	Terms   map[string][]Posting
+}
+
+// IndexDoc is one indexed message
+type IndexDoc struct {
+	UUID      string
+	Role      string // user, assistant, tool, system, summary, ...
+	Timestamp time.Time
+	Length    int    // Number of terms
+	Text      string // Truncated plain text, for snippets
+}
+
+// Posting records how often a term occurs in a document
+type Posting struct {
+	Doc  int32
+	Freq int32
+}
+
+// IndexHit is a ranked search result
+type IndexHit struct {
+	Path      string
+	Project   string
+	UUID      string
+	Role      string
+	Timestamp time.Time
+	Score     float64
+	Snippet   string
+}
+
+// IndexQuery is a parsed cross-session query
+type IndexQuery struct {
+	Terms   []string
+	After   time.Time // Inclusive; zero means unbounded
+	Before  time.Time // Exclusive; zero means unbounded
+	Project string    // Case-insensitive substring of the project directory
+	Role    string
+}
+
+// DefaultIndexPath returns where the index is stored, following the XDG base
+// directory spec: $XDG_CACHE_HOME/claude-history-reader/index.gob
+func DefaultIndexPath() (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "claude-history-reader", "index.gob"), nil
+}
+
+// LoadIndex reads the index at path. A missing or outdated index yields an
+// empty one.
+func LoadIndex(path string) (*Index, error) {
+	idx := &Index{Version: indexVersion, Files: make(map[string]*IndexedFile)}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return idx, err
+	}
+	defer f.Close()
+
+	var loaded Index
+	if err := gob.NewDecoder(f).Decode(&loaded); err != nil || loaded.Version != indexVersion {
+		// Corrupt or stale; rebuild from scratch
+		return idx, nil
+	}
+	if loaded.Files == nil {
+		loaded.Files = make(map[string]*IndexedFile)
+	}
+	return &loaded, nil
+}
+
+// Save writes the index to path atomically
+func (idx *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".index-*.gob")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(idx); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Update brings the index in line with the session files under root
+// (~/.claude/projects). Only files whose size or mtime changed are re-read.
+// Secrets found by redactor are masked before anything is indexed, so they
+// are neither kept in the cache nor shown in snippets; when the redaction
+// patterns change, every file is re-read. It returns the number of files
+// (re)indexed or removed.
+func (idx *Index) Update(root string, redactor *Redactor) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(root, "*", "*.jsonl"))
+	if err != nil {
+		return 0, err
+	}
+	if fingerprint := redactor.Fingerprint(); idx.Redactor != fingerprint {
+		idx.Redactor = fingerprint
+		idx.Files = make(map[string]*IndexedFile)
+	}
+
+	changed := 0
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		seen[path] = true
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if existing, ok := idx.Files[path]; ok &&
+			existing.Size == info.Size() && existing.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		messages, err := ParseJSONLMessages(path)
+		if err != nil {
+			continue
+		}
+		file := indexMessages(redactor.RedactMessages(messages))
+		file.Size = info.Size()
+		file.ModTime = info.ModTime()
+		file.Project = filepath.Base(filepath.Dir(path))
+		idx.Files[path] = file
+		changed++
+	}
+
+	// Drop sessions that no longer exist
+	for path := range idx.Files {
+		if !seen[path] {
+			delete(idx.Files, path)
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// indexMessages builds the index of a single session
+func indexMessages(messages []Message) *IndexedFile {
+	file := &IndexedFile{Terms: make(map[string][]Posting)}
+
+	for i := range messages {
+		msg := &messages[i]
+		text := msg.PlainText()
+		terms := tokenize(text)
+		if len(terms) == 0 {
+			continue
+		}
+
+		doc := int32(len(file.Docs))
+		file.Docs = append(file.Docs, IndexDoc{
+			UUID:      msg.UUID,
+			Role:      indexRole(msg),
+			Timestamp: msg.Timestamp,
+			Length:    len(terms),
+			Text:      truncateText(text, maxSnippetText),
+		})
+
+		freqs := make(map[string]int32)
+		for _, term := range terms {
+			freqs[term]++
+		}
+		for term, freq := range freqs {
+			file.Terms[term] = append(file.Terms[term], Posting{Doc: doc, Freq: freq})
+		}
+	}
+
+	return file
+}
+
+// indexRole returns the role a message is filtered by. Tool results get their
+// own role so that role:user means what the human typed.
+func indexRole(msg *Message) string {
+	if msg.HasToolResult() {
+		return "tool"
+	}
+	return msg.Type
+}
+
+// tokenize lowercases s and splits it into indexable terms
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := fields[:0]
+	for _, f := range fields {
+		if len(f) >= 2 && len(f) <= 40 {
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// ParseIndexQuery parses free-text terms plus after:, before:, project: and
+// role: filters. Dates are YYYY-MM-DD in local time.
+func ParseIndexQuery(q string) (IndexQuery, error) {
+	var query IndexQuery
+	for _, field := range strings.Fields(q) {
+		name, value, isFilter := strings.Cut(field, ":")
+		if !isFilter || value == "" {
+			query.Terms = append(query.Terms, tokenize(field)...)
+			continue
+		}
+
+		switch strings.ToLower(name) {
+		case "after":
+			t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return query, fmt.Errorf("after: expected a date like 2026-09-01, got %q", value)
+			}
+			query.After = t
+		case "before":
+			t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return query, fmt.Errorf("before: expected a date like 2026-09-01, got %q", value)
+			}
+			query.Before = t
+		case "project":
+			query.Project = strings.ToLower(value)
+		case "role":
+			query.Role = strings.ToLower(value)
+		default:
+			// Not a filter; treat as text (e.g. "http://...")
+			query.Terms = append(query.Terms, tokenize(field)...)
+		}
+	}
+	if len(query.Terms) == 0 {
+		return query, errors.New("enter at least one search term")
+	}
+	return query, nil
+}
+
+// matches reports whether a document passes the query's filters
+func (q IndexQuery) matches(file *IndexedFile, doc *IndexDoc) bool {
+	if q.Role != "" && doc.Role != q.Role {
+		return false
+	}
+	if q.Project != "" && !strings.Contains(strings.ToLower(file.Project), q.Project) {
+		return false
+	}
+	if !q.After.IsZero() && doc.Timestamp.Before(q.After) {
+		return false
+	}
+	if !q.Before.IsZero() && !doc.Timestamp.Before(q.Before) {
+		return false
+	}
+	return true
+}
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Search ranks documents against q with BM25 and returns at most limit hits
+func (idx *Index) Search(q IndexQuery, limit int) []IndexHit {
+	// Corpus statistics
+	totalDocs := 0
+	totalLength := 0
+	docFreq := make(map[string]int, len(q.Terms))
+	for _, file := range idx.Files {
+		totalDocs += len(file.Docs)
+		for _, doc := range file.Docs {
+			totalLength += doc.Length
+		}
+		for _, term := range q.Terms {
+			docFreq[term] += len(file.Terms[term])
+		}
+	}
+	if totalDocs == 0 {
+		return nil
+	}
+	avgLength := float64(totalLength) / float64(totalDocs)
+
+	type docKey struct {
+		path string
+		doc  int32
+	}
+	scores := make(map[docKey]float64)
+
+	for path, file := range idx.Files {
+		for _, term := range q.Terms {
+			df := docFreq[term]
+			if df == 0 {
+				continue
+			}
+			idf := math.Log(1 + (float64(totalDocs)-float64(df)+0.5)/(float64(df)+0.5))
+			for _, p := range file.Terms[term] {
+				doc := &file.Docs[p.Doc]
+				if !q.matches(file, doc) {
+					continue
+				}
+				tf := float64(p.Freq)
+				norm := tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.Length)/avgLength))
+				scores[docKey{path, p.Doc}] += idf * norm
+			}
+		}
+	}
+
+	hits := make([]IndexHit, 0, len(scores))
+	for key, score := range scores {
+		file := idx.Files[key.path]
+		doc := &file.Docs[key.doc]
+		hits = append(hits, IndexHit{
+			Path:      key.path,
+			Project:   file.Project,
+			UUID:      doc.UUID,
+			Role:      doc.Role,
+			Timestamp: doc.Timestamp,
+			Score:     score,
+			Snippet:   makeSnippet(doc.Text, q.Terms, 120),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Timestamp.After(hits[j].Timestamp)
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// makeSnippet returns a single-line excerpt of text around the first
+// occurrence of any term
+func makeSnippet(text string, terms []string, width int) string {
+	flat := strings.Join(strings.Fields(text), " ")
+	lower := strings.ToLower(flat)
+
+	pos := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (pos < 0 || i < pos) {
+			pos = i
+		}
+	}
+	if pos > len(flat) {
+		pos = len(flat) // Lowercasing changed byte lengths
+	}
+
+	runes := []rune(flat)
+	start := 0
+	if pos > 0 {
+		// Convert byte offset to rune offset and back off for context
+		start = len([]rune(flat[:pos])) - width/3
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + width
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PathQuery is a parsed jq/JSONPath-like expression that is run against every
+// record in JSON mode, e.g.
+//
+//	.message.usage.output_tokens
+//	.message.content[].name == "Bash"
+//	..input_tokens > 1000
+//
+// Supported steps are .key, ."quoted key", [n] (negative counts from the
+// end), [] / [*] / .* for all children and ..key for a key at any depth. A
+// leading $ is accepted for JSONPath habits. An optional comparison keeps only
+// values that satisfy it.
+type PathQuery struct {
+	steps   []pathStep
+	op      string      // Comparison operator, "" for none
+	literal interface{} // Right-hand side of the comparison
+}
+
+type pathStepKind int
+
+const (
+	pathKey       pathStepKind = iota // .key
+	pathIndex                         // [n]
+	pathAll                           // [] [*] .*
+	pathRecursive                     // ..key
+)
+
+type pathStep struct {
+	kind  pathStepKind
+	key   string
+	index int
+}
+
+// PathHit is one value selected by a path query
+type PathHit struct {
+	Record int         // Index into the session's records
+	Line   int         // Line of the value in the pretty-printed content
+	Path   string      // Concrete path, e.g. .message.content[2].name
+	Value  interface{} // Decoded value
+}
+
+// jsonRecord is one JSONL record and where it sits in the pretty-printed
+// content
+type jsonRecord struct {
+	Start, End int         // Line range, End exclusive
+	Value      interface{} // Decoded record, nil if the line wasn't JSON
+	OK         bool
+}
+
+// pathComparisons lists the operators, longest first so that >= wins over >
+var pathComparisons = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// ParsePathQuery parses a path expression with an optional comparison
+func ParsePathQuery(s string) (*PathQuery, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	q := &PathQuery{}
+	path := s
+	if i, op := findComparison(s); i >= 0 {
+		path = strings.TrimSpace(s[:i])
+		rhs := strings.TrimSpace(s[i+len(op):])
+		if rhs == "" {
+			return nil, fmt.Errorf("%s needs a value to compare with", op)
+		}
+		var literal interface{}
+		if err := json.Unmarshal([]byte(rhs), &literal); err != nil {
+			return nil, fmt.Errorf("can't parse %q as a JSON value (quote strings)", rhs)
+		}
+		q.op = op
+		q.literal = literal
+	}
+
+	steps, err := parsePathSteps(path)
+	if err != nil {
+		return nil, err
+	}
+	q.steps = steps
+	return q, nil
+}
+
+// findComparison returns the position of the first comparison operator that
+// is outside quotes, or -1
+func findComparison(s string) (int, string) {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuote:
+			i++
+		case s[i] == '"':
+			inQuote = !inQuote
+		case !inQuote:
+			for _, op := range pathComparisons {
+				if strings.HasPrefix(s[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+// parsePathSteps parses the path part of a query
+func parsePathSteps(s string) ([]pathStep, error) {
+	s = strings.TrimPrefix(s, "$")
+	if s == "" || s == "." {
+		return nil, nil // Identity: the whole record
+	}
+
+	var steps []pathStep
+	i := 0
+	for i < len(s) {
+		switch {
+		case strings.HasPrefix(s[i:], ".."):
+			key, next, err := parsePathKey(s, i+2)
+			if err != nil {
+				return nil, err
+			}
+			if key == "" {
+				return nil, fmt.Errorf("col %d: .. must be followed by a key", i+1)
+			}
+			steps = append(steps, pathStep{kind: pathRecursive, key: key})
+			i = next
+
+		case s[i] == '.':
+			if i+1 < len(s) && s[i+1] == '*' {
+				steps = append(steps, pathStep{kind: pathAll})
+				i += 2
+				continue
+			}
+			key, next, err := parsePathKey(s, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if key == "" {
+				if next < len(s) && s[next] == '[' {
+					i = next // ".[0]" is the same as "[0]"
+					continue
+				}
+				return nil, fmt.Errorf("col %d: expected a key after .", i+1)
+			}
+			steps = append(steps, pathStep{kind: pathKey, key: key})
+			i = next
+
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("col %d: unclosed [", i+1)
+			}
+			inner := strings.TrimSpace(s[i+1 : i+end])
+			switch {
+			case inner == "" || inner == "*":
+				steps = append(steps, pathStep{kind: pathAll})
+			case inner[0] == '"':
+				var key string
+				if err := json.Unmarshal([]byte(inner), &key); err != nil {
+					return nil, fmt.Errorf("col %d: bad quoted key %s", i+2, inner)
+				}
+				steps = append(steps, pathStep{kind: pathKey, key: key})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("col %d: expected an index, got %q", i+2, inner)
+				}
+				steps = append(steps, pathStep{kind: pathIndex, index: n})
+			}
+			i += end + 1
+
+		default:
+			return nil, fmt.Errorf("col %d: unexpected %q (paths start with .)", i+1, s[i])
+		}
+	}
+	return steps, nil
+}
+
+// parsePathKey reads a bare or quoted key starting at s[start]
+func parsePathKey(s string, start int) (string, int, error) {
+	if start < len(s) && s[start] == '"' {
+		end := start + 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return "", 0, fmt.Errorf("col %d: unterminated quote", start+1)
+		}
+		var key string
+		if err := json.Unmarshal([]byte(s[start:end+1]), &key); err != nil {
+			return "", 0, fmt.Errorf("col %d: bad quoted key", start+1)
+		}
+		return key, end + 1, nil
+	}
+
+	end := start
+	for end < len(s) && s[end] != '.' && s[end] != '[' {
+		end++
+	}
+	return s[start:end], end, nil
+}
+
+// splitRecords splits pretty-printed JSONL content into its records. Records
+// are separated by blank lines, which never occur inside pretty-printed JSON.
+func splitRecords(lines []string) []jsonRecord {
+	var records []jsonRecord
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		rec := jsonRecord{Start: start, End: end}
+		text := strings.Join(lines[start:end], "\n")
+		rec.OK = json.Unmarshal([]byte(text), &rec.Value) == nil
+		records = append(records, rec)
+		start = -1
+	}
+	for i, line := range lines {
+		if line == "" {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(lines))
+	return records
+}
+
+// pathNode is a value being walked, with its position
+type pathNode struct {
+	value interface{}
+	line  int
+	path  string
+}
+
+// Run evaluates the query against every record
+func (q *PathQuery) Run(records []jsonRecord) []PathHit {
+	var hits []PathHit
+	for r, rec := range records {
+		if !rec.OK {
+			continue
+		}
+		nodes := []pathNode{{value: rec.Value, line: rec.Start}}
+		for _, step := range q.steps {
+			nodes = applyPathStep(nodes, step)
+		}
+		for _, n := range nodes {
+			if q.op != "" && !compareJSON(n.value, q.op, q.literal) {
+				continue
+			}
+			path := n.path
+			if path == "" {
+				path = "."
+			}
+			hits = append(hits, PathHit{Record: r, Line: n.line, Path: path, Value: n.value})
+		}
+	}
+	return hits
+}
+
+// applyPathStep maps every node through one step
+func applyPathStep(nodes []pathNode, step pathStep) []pathNode {
+	var out []pathNode
+	for _, n := range nodes {
+		switch step.kind {
+		case pathKey:
+			for _, child := range jsonChildren(n) {
+				if child.key == step.key {
+					out = append(out, child.pathNode)
+				}
+			}
+
+		case pathIndex:
+			children := jsonChildren(n)
+			if _, isArray := n.value.([]interface{}); !isArray {
+				continue
+			}
+			i := step.index
+			if i < 0 {
+				i += len(children)
+			}
+			if i >= 0 && i < len(children) {
+				out = append(out, children[i].pathNode)
+			}
+
+		case pathAll:
+			for _, child := range jsonChildren(n) {
+				out = append(out, child.pathNode)
+			}
+
+		case pathRecursive:
+			out = append(out, findRecursive(n, step.key)...)
+		}
+	}
+	return out
+}
+
+// findRecursive returns every value under n stored at key, in document order
+func findRecursive(n pathNode, key string) []pathNode {
+	var out []pathNode
+	for _, child := range jsonChildren(n) {
+		if _, isObject := n.value.(map[string]interface{}); isObject && child.key == key {
+			out = append(out, child.pathNode)
+		}
+		out = append(out, findRecursive(child.pathNode, key)...)
+	}
+	return out
+}
+
+type jsonChild struct {
+	pathNode
+	key string // Object key; empty for array elements
+}
+
+// jsonChildren returns the members of an object (in the sorted key order
+// json.MarshalIndent uses) or the elements of an array, each with the line it
+// starts on
+func jsonChildren(n pathNode) []jsonChild {
+	var children []jsonChild
+	line := n.line + 1 // Skip the opening brace
+	switch v := n.value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			children = append(children, jsonChild{
+				pathNode: pathNode{value: v[k], line: line, path: n.path + pathKeySegment(k)},
+				key:      k,
+			})
+			line += jsonLineCount(v[k])
+		}
+	case []interface{}:
+		for i, elem := range v {
+			children = append(children, jsonChild{
+				pathNode: pathNode{value: elem, line: line, path: fmt.Sprintf("%s[%d]", n.path, i)},
+			})
+			line += jsonLineCount(elem)
+		}
+	}
+	return children
+}
+
+// pathKeySegment renders a key as a path step, quoting it when needed
+func pathKeySegment(key string) string {
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			quoted, _ := json.Marshal(key)
+			return "." + string(quoted)
+		}
+	}
+	return "." + key
+}
+
+// jsonLineCount returns how many lines json.MarshalIndent uses for v
+func jsonLineCount(v interface{}) int {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return 1
+		}
+		n := 2
+		for _, child := range v {
+			n += jsonLineCount(child)
+		}
+		return n
+	case []interface{}:
+		if len(v) == 0 {
+			return 1
+		}
+		n := 2
+		for _, child := range v {
+			n += jsonLineCount(child)
+		}
+		return n
+	default:
+		return 1
+	}
+}
+
+// compareJSON applies a comparison between two decoded JSON values. Ordering
+// works on numbers and strings; mismatched types only satisfy !=.
+func compareJSON(a interface{}, op string, b interface{}) bool {
+	switch op {
+	case "==":
+		return jsonEqual(a, b)
+	case "!=":
+		return !jsonEqual(a, b)
+	}
+
+	var cmp int
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case av < bv:
+			cmp = -1
+		case av > bv:
+			cmp = 1
+		}
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(av, bv)
+	default:
+		return false
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// jsonEqual compares decoded JSON values by their canonical encoding
+func jsonEqual(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// formatPathValue renders a hit's value on one line
+func formatPathValue(v interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func pathTestRecords(t *testing.T) ([]string, []jsonRecord) {
+	t.Helper()
+	data := `{"type":"user","message":{"role":"user","content":"hi <there>"}}
+not json
+{"type":"assistant","message":{"content":[{"type":"text","text":"ok"},{"type":"tool_use","name":"Bash","input":{"command":"ls"}}],"usage":{"input_tokens":1200,"output_tokens":35}}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read","input":{}}],"usage":{"input_tokens":800,"output_tokens":12}}}`
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ParseJSONLFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(content, "\n")
+	return lines, splitRecords(lines)
+}
+
+func TestSplitRecords(t *testing.T) {
+	lines, records := pathTestRecords(t)
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+	if records[1].OK {
+		t.Error("Non-JSON line should not decode")
+	}
+	last := records[3]
+	if last.End != len(lines) || lines[last.Start] != "{" {
+		t.Errorf("last record spans %d-%d", last.Start, last.End)
+	}
+}
+
+func TestPathQueryRun(t *testing.T) {
+	lines, records := pathTestRecords(t)
+
+	tests := []struct {
+		query  string
+		values string // Values of the hits, formatted
+		key    string // Key expected on each hit's line
+	}{
+		{".message.usage.output_tokens", "35 12", `"output_tokens"`},
+		{"$.message.content[].name", `"Bash" "Read"`, `"name"`},
+		{`.message.content[*].name == "Bash"`, `"Bash"`, `"name"`},
+		{"..input_tokens > 1000", "1200", `"input_tokens"`},
+		{".message.content[-1].type", `"tool_use" "tool_use"`, `"type"`},
+		{`.message["role"]`, `"user"`, `"role"`},
+		{`.message.content == "hi <there>"`, `"hi <there>"`, `"content"`},
+		{".type != \"user\"", `"assistant" "assistant"`, `"type"`},
+		{"..command", `"ls"`, `"command"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParsePathQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParsePathQuery(%q) failed: %v", tt.query, err)
+			}
+			hits := q.Run(records)
+			var values []string
+			for _, hit := range hits {
+				values = append(values, formatPathValue(hit.Value))
+				if !strings.Contains(lines[hit.Line], tt.key) {
+					t.Errorf("hit %s points at line %d %q, want a line with %s", hit.Path, hit.Line, lines[hit.Line], tt.key)
+				}
+			}
+			if got := strings.Join(values, " "); got != tt.values {
+				t.Errorf("values = %s, want %s", got, tt.values)
+			}
+		})
+	}
+}
+
+func TestParsePathQueryErrors(t *testing.T) {
+	for _, query := range []string{"", "message", ".a[", ".a[x]", "..", ".a ==", ".a == bare", `."open`} {
+		if _, err := ParsePathQuery(query); err == nil {
+			t.Errorf("ParsePathQuery(%q) should fail", query)
+		}
+	}
+}
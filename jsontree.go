@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// jsonNode is a value in the tree of a pretty-printed JSON record, with the
+// lines it spans
+type jsonNode struct {
+	Key        string // Object key; empty for array elements and records
+	HasKey     bool
+	Value      interface{}
+	Start, End int // Line range in the full content, End exclusive
+	Depth      int // 0 for records
+	Children   []*jsonNode
+}
+
+// foldable reports whether the node spans lines that can be folded away
+func (n *jsonNode) foldable() bool {
+	return n.End-n.Start > 1
+}
+
+// buildJSONTree builds the tree of a record, or returns nil when the record
+// isn't JSON or doesn't have the layout json.MarshalIndent gives it
+func buildJSONTree(rec jsonRecord) *jsonNode {
+	if !rec.OK {
+		return nil
+	}
+	root := newJSONNode(rec.Value, rec.Start, 0)
+	if root.End != rec.End {
+		return nil
+	}
+	return root
+}
+
+// buildJSONTrees builds the tree of every record
+func buildJSONTrees(records []jsonRecord) []*jsonNode {
+	roots := make([]*jsonNode, len(records))
+	for r, rec := range records {
+		roots[r] = buildJSONTree(rec)
+	}
+	return roots
+}
+
+func newJSONNode(value interface{}, start, depth int) *jsonNode {
+	n := &jsonNode{Value: value, Start: start, End: start + jsonLineCount(value), Depth: depth}
+	line := start + 1 // Skip the opening brace
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := newJSONNode(v[k], line, depth+1)
+			child.Key, child.HasKey = k, true
+			n.Children = append(n.Children, child)
+			line = child.End
+		}
+	case []interface{}:
+		for _, elem := range v {
+			child := newJSONNode(elem, line, depth+1)
+			n.Children = append(n.Children, child)
+			line = child.End
+		}
+	}
+	return n
+}
+
+// nodeAt returns the innermost foldable node containing line, or nil
+func (n *jsonNode) nodeAt(line int) *jsonNode {
+	if n == nil || line < n.Start || line >= n.End || !n.foldable() {
+		return nil
+	}
+	i := sort.Search(len(n.Children), func(i int) bool { return n.Children[i].End > line })
+	if i < len(n.Children) {
+		if child := n.Children[i].nodeAt(line); child != nil {
+			return child
+		}
+	}
+	return n
+}
+
+// errJSONLayout means a line doesn't look the way the tree says it should
+var errJSONLayout = errors.New("unexpected JSON layout")
+
+// renderJSONLines syntax-highlights the pretty-printed content from the
+// record trees: each token's style comes from its node, not from lexing the
+// line. Records without a tree, or whose lines don't match it, fall back to
+// the line lexer.
+func renderJSONLines(lines []string, records []jsonRecord, roots []*jsonNode) []string {
+	out := make([]string, len(lines))
+	copy(out, lines)
+	for r, rec := range records {
+		if roots[r] != nil && renderJSONNode(roots[r], lines, out, true) == nil {
+			continue
+		}
+		for line := rec.Start; line < rec.End; line++ {
+			out[line] = highlightJSONLine(lines[line])
+		}
+	}
+	return out
+}
+
+// renderJSONNode highlights the lines of n into out. last says whether n is
+// the last member of its parent, so has no trailing comma.
+func renderJSONNode(n *jsonNode, lines, out []string, last bool) error {
+	indent, key, rest, err := n.splitLine(lines[n.Start])
+	if err != nil {
+		return err
+	}
+	comma := ""
+	if !last {
+		if !strings.HasSuffix(rest, ",") {
+			return errJSONLayout
+		}
+		comma = ","
+		rest = strings.TrimSuffix(rest, ",")
+	}
+
+	var b strings.Builder
+	b.WriteString(indent)
+	if key != "" {
+		b.WriteString(keyStyle.Render(key) + ": ")
+	}
+
+	switch n.Value.(type) {
+	case map[string]interface{}, []interface{}:
+		open, close := "{", "}"
+		if _, ok := n.Value.([]interface{}); ok {
+			open, close = "[", "]"
+		}
+		if !n.foldable() {
+			if rest != open+close {
+				return errJSONLayout
+			}
+			out[n.Start] = b.String() + braceStyle.Render(rest) + comma
+			return nil
+		}
+		if rest != open || lines[n.End-1] != indent+close+comma {
+			return errJSONLayout
+		}
+		out[n.Start] = b.String() + braceStyle.Render(open)
+		for i, child := range n.Children {
+			if err := renderJSONNode(child, lines, out, i == len(n.Children)-1); err != nil {
+				return err
+			}
+		}
+		out[n.End-1] = indent + braceStyle.Render(close) + comma
+		return nil
+
+	case string:
+		b.WriteString(strStyle.Render(rest))
+	case float64:
+		b.WriteString(numStyle.Render(rest))
+	default: // Booleans and null
+		b.WriteString(boolStyle.Render(rest))
+	}
+	out[n.Start] = b.String() + comma
+	return nil
+}
+
+// splitLine splits the first line of n into its indentation, its quoted key
+// (empty for array elements and records) and the rest
+func (n *jsonNode) splitLine(line string) (indent, key, rest string, err error) {
+	indent = strings.Repeat("    ", n.Depth)
+	if !strings.HasPrefix(line, indent) {
+		return "", "", "", errJSONLayout
+	}
+	rest = line[len(indent):]
+	if n.HasKey {
+		quoted, _ := json.Marshal(n.Key) // Escapes like MarshalIndent does
+		key = string(quoted)
+		if !strings.HasPrefix(rest, key+": ") {
+			return "", "", "", errJSONLayout
+		}
+		rest = rest[len(key)+2:]
+	}
+	return indent, key, rest, nil
+}
+
+// foldedLine renders a folded node as one line, plain and highlighted:
+// records as `{type: assistant, uuid: 1b2c3d4e…}`, other nodes as `{…}`,
+// followed by the child count and size
+func (n *jsonNode) foldedLine(lines []string) (raw, highlighted string) {
+	indent, key, _, err := n.splitLine(lines[n.Start])
+	if err != nil {
+		indent, key = "", ""
+	}
+	comma := ""
+	if strings.HasSuffix(lines[n.End-1], ",") {
+		comma = ","
+	}
+
+	open, close, unit := "{", "}", "key"
+	if _, ok := n.Value.([]interface{}); ok {
+		open, close, unit = "[", "]", "item"
+	}
+	body := "…"
+	if obj, ok := n.Value.(map[string]interface{}); ok && n.Depth == 0 {
+		body = recordSummary(obj)
+	}
+	size, _ := json.Marshal(n.Value)
+	stats := fmt.Sprintf("%d %s · %s", len(n.Children), plural(len(n.Children), unit), formatSize(len(size)))
+
+	prefix, highlightedPrefix := indent, indent
+	if key != "" {
+		prefix += key + ": "
+		highlightedPrefix += keyStyle.Render(key) + ": "
+	}
+	raw = prefix + open + body + close + comma + "  " + stats
+	highlighted = highlightedPrefix + braceStyle.Render(open) + body + braceStyle.Render(close) + comma +
+		"  " + helpStyle.Render(stats)
+	return raw, highlighted
+}
+
+// recordSummary describes a record by its identifying fields
+func recordSummary(obj map[string]interface{}) string {
+	var fields []string
+	for _, key := range []string{"type", "subtype", "uuid"} {
+		value, ok := obj[key].(string)
+		if !ok || value == "" {
+			continue
+		}
+		if key == "uuid" && len(value) > 8 {
+			value = value[:8] + "…"
+		}
+		fields = append(fields, key+": "+value)
+	}
+	if len(fields) == 0 {
+		return "…"
+	}
+	return strings.Join(fields, ", ")
+}
+
+// plural adds an "s" to unit unless n is 1
+func plural(n int, unit string) string {
+	if n == 1 {
+		return unit
+	}
+	return unit + "s"
+}
+
+// formatSize renders a byte count, e.g. "512 B" or "3.4 KB"
+func formatSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
+
+// jsonNodeFolded reports whether a node is folded: by its own toggle, or else
+// by the fold depth
+func (m *Model) jsonNodeFolded(n *jsonNode) bool {
+	if !n.foldable() {
+		return false
+	}
+	fs := m.folds()
+	if folded, ok := fs.jsonNodes[n.Start]; ok {
+		return folded
+	}
+	return fs.jsonDepth >= 0 && n.Depth >= fs.jsonDepth
+}
+
+// appendRecordLines adds the lines of record r to the displayed JSON lines,
+// replacing each folded node with its one-line summary
+func (m *Model) appendRecordLines(r int) {
+	rec := m.records[r]
+	root := m.jsonRoots[r]
+	for line := rec.Start; line < rec.End; {
+		if n := m.foldedNodeAt(root, line); n != nil {
+			raw, highlighted := n.foldedLine(m.fullRawLines)
+			m.rawLines = append(m.rawLines, raw)
+			m.highlightedLines = append(m.highlightedLines, highlighted)
+			m.lineOrigin = append(m.lineOrigin, line)
+			m.lineEnd = append(m.lineEnd, n.End)
+			line = n.End
+			continue
+		}
+		m.rawLines = append(m.rawLines, m.fullRawLines[line])
+		m.highlightedLines = append(m.highlightedLines, m.fullHighlightedLines[line])
+		m.lineOrigin = append(m.lineOrigin, line)
+		m.lineEnd = append(m.lineEnd, line+1)
+		line++
+	}
+}
+
+// foldedNodeAt returns the outermost folded node starting at line, or nil
+func (m *Model) foldedNodeAt(n *jsonNode, line int) *jsonNode {
+	for n != nil && n.Start <= line && line < n.End {
+		if n.Start == line && m.jsonNodeFolded(n) {
+			return n
+		}
+		i := sort.Search(len(n.Children), func(i int) bool { return n.Children[i].End > line })
+		if i >= len(n.Children) {
+			return nil
+		}
+		n = n.Children[i]
+	}
+	return nil
+}
+
+// jsonNodeAtCursor returns the innermost foldable node around the JSON
+// cursor, or nil
+func (m Model) jsonNodeAtCursor() *jsonNode {
+	line := m.originLine(m.cursorLine)
+	r := m.recordAtLine(line)
+	if r < 0 {
+		return nil
+	}
+	return m.jsonRoots[r].nodeAt(line)
+}
+
+// handleJSONFoldKey handles the key after a "z" prefix in JSON mode
+func (m *Model) handleJSONFoldKey(key string) {
+	fs := m.folds()
+	switch key {
+	case "a", "o", "c": // Toggle, open or close the node at the cursor
+		n := m.jsonNodeAtCursor()
+		if n == nil {
+			return
+		}
+		folded := key == "c" || key == "a" && !m.jsonNodeFolded(n)
+		fs.jsonNodes[n.Start] = folded
+		if folded {
+			// Close the node itself, not a fold it sits in
+			m.cursorLine = m.displayLine(n.Start)
+		}
+
+	case "M": // Every record on one line
+		m.setJSONFoldDepth(0)
+	case "R": // Unfold everything
+		m.setJSONFoldDepth(-1)
+		m.statusMessage = "Unfolded all"
+
+	default:
+		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+			m.setJSONFoldDepth(int(key[0] - '0'))
+		} else {
+			return
+		}
+	}
+
+	m.applyRecordFilter() // Keeps the cursor on its line, or the fold over it
+	m.ensureCursorVisible()
+}
+
+// setJSONFoldDepth folds every node at depth and deeper (-1 folds nothing),
+// dropping per-node toggles
+func (m *Model) setJSONFoldDepth(depth int) {
+	fs := m.folds()
+	fs.jsonDepth = depth
+	fs.jsonNodes = make(map[int]bool)
+	if depth == 0 {
+		m.statusMessage = "Folded every record"
+	} else if depth > 0 {
+		m.statusMessage = fmt.Sprintf("Folded to depth %d", depth)
+	}
+}
+
+// jsonFolded reports whether any JSON node may be folded
+func (fs *foldState) jsonFolded() bool {
+	if fs.jsonDepth >= 0 {
+		return true
+	}
+	for _, folded := range fs.jsonNodes {
+		if folded {
+			return true
+		}
+	}
+	return false
+}
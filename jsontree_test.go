@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const jsonTreeSession = `{"type":"assistant","uuid":"0123456789abcdef","message":{"content":[{"type":"text","text":"a <b> & c"}],"usage":{"input_tokens":12,"cached":null,"ok":true}}}
+{"type":"user","uuid":"fedcba9876543210","message":{"content":"hi"}}`
+
+func openJSONTreeSession(t *testing.T) Model {
+	t.Helper()
+	testFile := filepath.Join(t.TempDir(), "test.jsonl")
+	if err := os.WriteFile(testFile, []byte(jsonTreeSession), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	m := Model{width: 100, height: 40}
+	if err := m.openSession(testFile); err != nil {
+		t.Fatal(err)
+	}
+	m.viewMode = ViewModeJSON
+	return m
+}
+
+func TestRenderJSONLinesFromTree(t *testing.T) {
+	m := openJSONTreeSession(t)
+	for i, line := range m.fullHighlightedLines {
+		if StripAnsi(line) != m.fullRawLines[i] {
+			t.Errorf("line %d = %q, want %q", i, StripAnsi(line), m.fullRawLines[i])
+		}
+	}
+	root := m.jsonRoots[0]
+	if root == nil || root.Start != 0 || root.End != m.records[0].End {
+		t.Fatalf("Bad root for record 0: %+v", root)
+	}
+	usage := root.Children[0].Children[1] // message.usage
+	if usage.Key != "usage" || m.fullRawLines[usage.Start] != `        "usage": {` {
+		t.Errorf("usage node at line %d: %q", usage.Start, m.fullRawLines[usage.Start])
+	}
+
+	// Lines that don't follow MarshalIndent's layout still highlight
+	input := "{\n    \"b\": 1,\n    \"a\": \"x\"\n}"
+	if got := StripAnsi(HighlightJSON(input)); got != input {
+		t.Errorf("Fallback changed the text: %q", got)
+	}
+}
+
+func TestJSONFolds(t *testing.T) {
+	m := openJSONTreeSession(t)
+	total := len(m.rawLines)
+
+	m.handleJSONFoldKey("M")
+	if len(m.rawLines) != 3 {
+		t.Fatalf("Folding every record gave %d lines, want 3:\n%s", len(m.rawLines), strings.Join(m.rawLines, "\n"))
+	}
+	if got := m.rawLines[0]; got != "{type: assistant, uuid: 01234567…}  3 keys · 172 B" {
+		t.Errorf("Record summary = %q", got)
+	}
+
+	// Opening the record leaves its children folded at the same depth
+	m.cursorLine = 0
+	m.handleJSONFoldKey("o")
+	if got := m.rawLines[1]; !strings.HasPrefix(got, `    "message": {…},  2 keys · `) {
+		t.Errorf("Child summary = %q", got)
+	}
+
+	// Lines inside a fold map to it
+	usage := m.jsonRoots[0].Children[0].Children[1]
+	if got := m.displayLine(usage.Start + 1); got != 1 {
+		t.Errorf("displayLine inside a fold = %d, want 1", got)
+	}
+
+	m.handleJSONFoldKey("R")
+	if len(m.rawLines) != total || m.lineOrigin != nil {
+		t.Errorf("Unfolding all gave %d lines, want %d", len(m.rawLines), total)
+	}
+
+	// za folds the node around the cursor and moves onto it
+	m.cursorLine = m.displayLine(usage.Start + 2)
+	m.handleJSONFoldKey("a")
+	if m.originLine(m.cursorLine) != usage.Start || !strings.Contains(m.rawLines[m.cursorLine], `"usage": {…}`) {
+		t.Errorf("za left the cursor on %q", m.rawLines[m.cursorLine])
+	}
+}
+
+func TestSearchInsideJSONFolds(t *testing.T) {
+	m := openJSONTreeSession(t)
+	m.handleJSONFoldKey("M")
+
+	m.applySearch("input_tokens")
+	if len(m.searchHits) != 1 || m.searchHits[0] != 0 {
+		t.Fatalf("A match inside a folded record should hit the fold, got %v", m.searchHits)
+	}
+	m.cursorLine = 2
+	m.findNext(1)
+	if m.cursorLine != 0 {
+		t.Errorf("n should move onto the fold, got line %d", m.cursorLine)
+	}
+
+	m.applySearch("hi")
+	if len(m.searchHits) != 1 || m.searchHits[0] != 2 {
+		t.Errorf("Hits = %v, want the second record's fold", m.searchHits)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import "fmt"
+
+// KeysConfig remaps keys. Each entry binds a key to the built-in key whose
+// action it performs, e.g. {"ctrl+n": "j"}; binding a key to "" disables it.
+// Keys not mentioned keep their built-in actions.
+type KeysConfig struct {
+	FileList map[string]string `json:"file_list"`
+	Viewer   map[string]string `json:"viewer"`
+}
+
+// fileListKeys are the built-in keys of the session list
+var fileListKeys = keySet("q", "ctrl+c", "j", "down", "k", "up", "enter", "S", "g", "G")
+
+// viewerKeys are the built-in keys of the viewer, including the second keys
+// of the z, f and y prefixes and the keys of the focused preview
+var viewerKeys = keySet(
+	"tab", "esc", "enter", "ctrl+c", "up", "down", " ",
+	"ctrl+d", "ctrl+u", "ctrl+e", "ctrl+y", "ctrl+f", "ctrl+b",
+	"0", "1", "2", "3", "4", "5", "6", "7", "8", "9",
+	"a", "A", "b", "c", "e", "E", "f", "F", "g", "G", "i", "j", "J", "k", "K",
+	"m", "M", "n", "N", "o", "p", "P", "q", "r", "R", "s", "S", "t", "u",
+	"v", "V", "x", "y", "z",
+	"/", "&", "|", "{", "}", "[", "]", "(", ")", "<", ">", "'",
+)
+
+func keySet(keys ...string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// remapKey returns the built-in key a pressed key is bound to: itself unless
+// the config rebinds it, and "" if the config disables it
+func remapKey(bindings map[string]string, key string) string {
+	if builtin, ok := bindings[key]; ok {
+		return builtin
+	}
+	return key
+}
+
+func (k KeysConfig) validate() error {
+	if err := validateBindings("keys.file_list", k.FileList, fileListKeys); err != nil {
+		return err
+	}
+	return validateBindings("keys.viewer", k.Viewer, viewerKeys)
+}
+
+func validateBindings(name string, bindings map[string]string, builtin map[string]bool) error {
+	for _, key := range sortedKeys(bindings) {
+		if key == "" {
+			return fmt.Errorf("%s: empty key", name)
+		}
+		if target := bindings[key]; target != "" && !builtin[target] {
+			return fmt.Errorf("%s[%q]: %q is not a built-in key here", name, key, target)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import "strings"
+
+// ContentKind classifies message content for the Message mode show/hide
+// toggles
+type ContentKind int
+
+const (
+	KindUserPrompt ContentKind = iota
+	KindAssistantText
+	KindThinking
+	KindToolUse
+	KindToolResult
+	KindSystem
+	KindSummary
+	KindMeta
+	KindReminder
+	numContentKinds
+)
+
+// kindToggles describes each kind: its toggle key after "f" and its label
+var kindToggles = [numContentKinds]struct {
+	key   string
+	label string
+}{
+	KindUserPrompt:    {"u", "prompts"},
+	KindAssistantText: {"a", "assistant"},
+	KindThinking:      {"t", "thinking"},
+	KindToolUse:       {"c", "tool calls"},
+	KindToolResult:    {"r", "tool results"},
+	KindSystem:        {"s", "system"},
+	KindSummary:       {"S", "summaries"},
+	KindMeta:          {"m", "meta"},
+	KindReminder:      {"i", "reminders"},
+}
+
+func (k ContentKind) String() string {
+	return kindToggles[k].label
+}
+
+// messageKind classifies a message as a whole, for messages without blocks
+func messageKind(msg *Message) ContentKind {
+	switch {
+	case msg.IsMeta:
+		return KindMeta
+	case msg.Type == "system":
+		return KindSystem
+	case msg.Type == "summary":
+		return KindSummary
+	case msg.HasToolResult():
+		return KindToolResult
+	case msg.Type == "user" && onlyReminders(msg):
+		return KindReminder
+	case msg.Type == "user":
+		return KindUserPrompt
+	default:
+		return KindAssistantText
+	}
+}
+
+// onlyReminders reports whether all of msg's content was injected
+func onlyReminders(msg *Message) bool {
+	for _, block := range msg.Content {
+		if block.Type != blockReminder {
+			return false
+		}
+	}
+	return len(msg.Content) > 0
+}
+
+// blockKind classifies one content block of msg
+func blockKind(msg *Message, block ContentBlock) ContentKind {
+	if msg.IsMeta || msg.Type == "system" || msg.Type == "summary" {
+		return messageKind(msg)
+	}
+	switch block.Type {
+	case "thinking":
+		return KindThinking
+	case "tool_use":
+		return KindToolUse
+	case "tool_result":
+		return KindToolResult
+	case blockReminder:
+		return KindReminder
+	}
+	return messageKind(msg)
+}
+
+// blockDisplays returns how each block of message i is shown, combining the
+// kind toggles with the folds
+func (m *Model) blockDisplays(i int) []BlockDisplay {
+	msg := &m.messages[i]
+	display := make([]BlockDisplay, len(msg.Content))
+	for j, block := range msg.Content {
+		switch {
+		case m.hiddenKinds[blockKind(msg, block)]:
+			display[j] = BlockHidden
+		case m.blockCollapsed(i, j):
+			display[j] = BlockCollapsed
+		}
+	}
+	return display
+}
+
+// kindVisible reports whether any of message i survives the kind toggles
+func (m Model) kindVisible(i int) bool {
+	msg := &m.messages[i]
+	if len(msg.Content) == 0 {
+		return !m.hiddenKinds[messageKind(msg)]
+	}
+	for _, block := range msg.Content {
+		if !m.hiddenKinds[blockKind(msg, block)] {
+			return true
+		}
+	}
+	return false
+}
+
+// handleKindToggleKey handles the key after an "f" prefix: the kind's key
+// toggles it and "x" shows everything again
+func (m *Model) handleKindToggleKey(key string) {
+	switch key {
+	case "x":
+		m.hiddenKinds = [numContentKinds]bool{}
+		m.statusMessage = "Showing all kinds"
+	default:
+		kind := ContentKind(-1)
+		for k, toggle := range kindToggles {
+			if toggle.key == key {
+				kind = ContentKind(k)
+			}
+		}
+		if kind < 0 {
+			return
+		}
+		m.hiddenKinds[kind] = !m.hiddenKinds[kind]
+		if m.hiddenKinds[kind] {
+			m.statusMessage = "Hiding " + kind.String()
+		} else {
+			m.statusMessage = "Showing " + kind.String()
+		}
+	}
+
+	m.rerenderAnchored(blockRef{Msg: m.msgCursor, Block: -1})
+}
+
+// kindIndicator renders the hidden kinds for the header, e.g.
+// "[hide thinking,tool results]"
+func (m Model) kindIndicator() string {
+	var hidden []string
+	for k, h := range m.hiddenKinds {
+		if h {
+			hidden = append(hidden, ContentKind(k).String())
+		}
+	}
+	if len(hidden) == 0 {
+		return ""
+	}
+	return "  " + statusStyle.Render("[hide "+strings.Join(hidden, ",")+"]")
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestBlockKind(t *testing.T) {
+	tests := []struct {
+		msg   Message
+		block ContentBlock
+		want  ContentKind
+	}{
+		{Message{Type: "user"}, ContentBlock{Type: "text"}, KindUserPrompt},
+		{Message{Type: "user"}, ContentBlock{Type: "tool_result"}, KindToolResult},
+		{Message{Type: "user", IsMeta: true}, ContentBlock{Type: "text"}, KindMeta},
+		{Message{Type: "assistant"}, ContentBlock{Type: "text"}, KindAssistantText},
+		{Message{Type: "assistant"}, ContentBlock{Type: "thinking"}, KindThinking},
+		{Message{Type: "assistant"}, ContentBlock{Type: "tool_use"}, KindToolUse},
+		{Message{Type: "system"}, ContentBlock{Type: "plain"}, KindSystem},
+		{Message{Type: "summary"}, ContentBlock{Type: "plain"}, KindSummary},
+	}
+	for _, tt := range tests {
+		tt.msg.Content = []ContentBlock{tt.block}
+		if got := blockKind(&tt.msg, tt.block); got != tt.want {
+			t.Errorf("blockKind(%s, %s) = %v, want %v", tt.msg.Type, tt.block.Type, got, tt.want)
+		}
+	}
+}
+
+func TestHiddenKindsLayout(t *testing.T) {
+	m := Model{
+		width: 80,
+		messages: []Message{
+			{Type: "user", Content: []ContentBlock{{Type: "text", Content: "Run the tests"}}},
+			{Type: "assistant", Content: []ContentBlock{
+				{Type: "thinking", Content: "Hmm"},
+				{Type: "tool_use", Name: "Bash", Content: `{"command": "go test"}`},
+			}},
+			{Type: "assistant", Content: []ContentBlock{{Type: "thinking", Content: "Done"}}},
+		},
+	}
+	m.hiddenKinds[KindThinking] = true
+	m.renderThread(m.width - 2)
+
+	if m.layout.msgStart[2] != -1 {
+		t.Error("A message with only hidden blocks should be hidden")
+	}
+	if m.layout.msgStart[1] < 0 || m.layout.blockStart[1][0] != -1 || m.layout.blockStart[1][1] < 0 {
+		t.Errorf("Only the thinking block should be hidden: %v", m.layout.blockStart[1])
+	}
+	if got := m.visibleMessageCount(); got != 2 {
+		t.Errorf("visibleMessageCount() = %d, want 2", got)
+	}
+}
@@ -1,13 +1,113 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 func main() {
+	exportHTML := flag.String("export-html", "", "write a self-contained HTML export of the given session `file` and exit")
+	exportMarkdown := flag.String("export-md", "", "write a Markdown export of the given session `file` and exit")
+	noRedact := flag.Bool("no-redact", false, "don't mask secrets in exports and query results")
+	secrets := flag.Bool("secrets", false, "list secrets found in the given session files and exit")
+	query := flag.String("query", "", "list messages in the given session files matching the `expr` and exit, e.g. 'role:assistant tool:Bash is:error'")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [session.jsonl]\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	redactor, err := NewRedactor(cfg.Redaction.Patterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Secrets report
+	if *secrets {
+		if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Error: -secrets requires one or more session .jsonl file arguments")
+			os.Exit(2)
+		}
+		for _, path := range flag.Args() {
+			messages, err := ParseJSONLMessages(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			fmt.Print(FormatFindings(path, redactor.Scan(messages)))
+		}
+		return
+	}
+
+	// Query report
+	if *query != "" {
+		if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Error: -query requires one or more session .jsonl file arguments")
+			os.Exit(2)
+		}
+		q, err := ParseQuery(*query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in query: %v\n  %s\n", err, *query)
+			if qerr, ok := err.(*QueryError); ok {
+				fmt.Fprintf(os.Stderr, "  %s^\n", strings.Repeat(" ", qerr.Pos))
+			}
+			os.Exit(2)
+		}
+		for _, path := range flag.Args() {
+			messages, err := ParseJSONLMessages(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			if !*noRedact {
+				messages = redactor.RedactMessages(messages)
+			}
+			fmt.Print(q.FormatMatches(path, messages))
+		}
+		return
+	}
+
+	// Non-interactive export
+	if *exportHTML != "" || *exportMarkdown != "" {
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Error: -export-html and -export-md require a session .jsonl file argument")
+			os.Exit(2)
+		}
+		exportRedactor := redactor
+		if *noRedact || !cfg.Redaction.Exports {
+			exportRedactor = nil
+		}
+		var bookmarks *Bookmarks
+		if path, err := DefaultBookmarksPath(); err == nil {
+			if bookmarks, err = LoadBookmarks(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: exporting without bookmarks: %v\n", err)
+			}
+		}
+		if *exportHTML != "" {
+			err = ExportHTMLFile(flag.Arg(0), *exportHTML, exportRedactor, bookmarks)
+		}
+		if err == nil && *exportMarkdown != "" {
+			err = ExportMarkdownFile(flag.Arg(0), *exportMarkdown, exportRedactor, bookmarks)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error exporting session: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Get current directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -31,10 +131,23 @@ func main() {
 	}
 
 	// Create and run the TUI
-	model := NewModel(files, projectPath)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	theme := resolveTheme(cfg.Theme, lipgloss.HasDarkBackground)
+	applyTheme(theme)
+	if theme.NoColor {
+		// lipgloss drops bold and reverse video along with colour under
+		// NO_COLOR; the no-colour theme needs them to mark the selection
+		lipgloss.SetColorProfile(termenv.ANSI)
+	} else {
+		applyColors(cfg.Colors)
+	}
+	model := NewModel(files, projectPath, cfg, redactor)
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(terminal))
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if m, ok := final.(Model); ok {
+		m.removeTempFiles()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
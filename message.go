@@ -25,11 +25,26 @@ type Message struct {
 
 // ContentBlock represents a piece of content within a message
 type ContentBlock struct {
-	Type    string // "text", "thinking", "tool_use", "tool_result", "plain"
+	Type    string // "text", "thinking", "tool_use", "tool_result", "plain", or a tagged block type (tagged.go)
 	Content string // The actual content
 	Name    string // For tool_use: tool name
+	IsError bool   // For tool_result: the tool reported an error
+	ID      string // For tool_use: its id; for tool_result: the id of its call
+	Tool    string // For tool_result: the name of the paired tool_use
+	Path    string // For tool_result: the file path of the paired tool_use, if any
+	Args    string // For command: its arguments
+	Note    string // For command: its status message
 }
 
+// BlockDisplay says how a content block is rendered
+type BlockDisplay int
+
+const (
+	BlockExpanded  BlockDisplay = iota
+	BlockCollapsed              // One-line summary
+	BlockHidden                 // Not rendered at all
+)
+
 // Message type styles
 var (
 	userBadgeStyle = lipgloss.NewStyle().
@@ -127,9 +142,44 @@ func ParseJSONLMessages(path string) ([]Message, error) {
 		}
 	}
 
+	pairToolResults(messages)
 	return messages, scanner.Err()
 }
 
+// pairToolResults tells each tool_result which tool call it answers and the
+// file that call worked on, so the result can be highlighted for it
+func pairToolResults(messages []Message) {
+	calls := make(map[string]*ContentBlock)
+	for i := range messages {
+		for j := range messages[i].Content {
+			block := &messages[i].Content[j]
+			switch {
+			case block.Type == "tool_use" && block.ID != "":
+				calls[block.ID] = block
+			case block.Type == "tool_result":
+				if call, ok := calls[block.ID]; ok {
+					block.Tool = call.Name
+					block.Path = toolFilePath(call.Content)
+				}
+			}
+		}
+	}
+}
+
+// toolFilePath returns the file a tool_use block's JSON input refers to
+func toolFilePath(input string) string {
+	var args toolInput
+	if json.Unmarshal([]byte(input), &args) != nil {
+		return ""
+	}
+	for _, field := range []string{"file_path", "notebook_path"} {
+		if path := args.str(field); path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
 func parseMessage(raw map[string]interface{}) *Message {
 	msgType, _ := raw["type"].(string)
 
@@ -183,13 +233,18 @@ func parseMessageContent(raw map[string]interface{}) []ContentBlock {
 
 	content := msgObj["content"]
 
+	// User text can carry tagged commands, command output and reminders
+	textBlocks := func(text string) []ContentBlock {
+		if raw["type"] == "user" {
+			return parseTaggedText(text, "text")
+		}
+		return []ContentBlock{{Type: "text", Content: text}}
+	}
+
 	// Content can be a string or an array
 	switch c := content.(type) {
 	case string:
-		blocks = append(blocks, ContentBlock{
-			Type:    "text",
-			Content: c,
-		})
+		blocks = append(blocks, textBlocks(c)...)
 
 	case []interface{}:
 		for _, item := range c {
@@ -203,10 +258,7 @@ func parseMessageContent(raw map[string]interface{}) []ContentBlock {
 			switch blockType {
 			case "text":
 				text, _ := itemMap["text"].(string)
-				blocks = append(blocks, ContentBlock{
-					Type:    "text",
-					Content: text,
-				})
+				blocks = append(blocks, textBlocks(text)...)
 
 			case "thinking":
 				thinking, _ := itemMap["thinking"].(string)
@@ -217,12 +269,14 @@ func parseMessageContent(raw map[string]interface{}) []ContentBlock {
 
 			case "tool_use":
 				name, _ := itemMap["name"].(string)
+				id, _ := itemMap["id"].(string)
 				input := itemMap["input"]
 				inputJSON, _ := json.MarshalIndent(input, "", "    ")
 				blocks = append(blocks, ContentBlock{
 					Type:    "tool_use",
 					Name:    name,
 					Content: string(inputJSON),
+					ID:      id,
 				})
 
 			case "tool_result":
@@ -233,9 +287,13 @@ func parseMessageContent(raw map[string]interface{}) []ContentBlock {
 					pretty, _ := json.MarshalIndent(parsed, "", "    ")
 					resultContent = string(pretty)
 				}
+				isError, _ := itemMap["is_error"].(bool)
+				id, _ := itemMap["tool_use_id"].(string)
 				blocks = append(blocks, ContentBlock{
 					Type:    "tool_result",
 					Content: resultContent,
+					IsError: isError,
+					ID:      id,
 				})
 			}
 		}
@@ -246,10 +304,8 @@ func parseMessageContent(raw map[string]interface{}) []ContentBlock {
 
 func parseSystemContent(raw map[string]interface{}) []ContentBlock {
 	content, _ := raw["content"].(string)
-	return []ContentBlock{{
-		Type:    "plain",
-		Content: content,
-	}}
+	// Local command records carry the same tags as user records
+	return parseTaggedText(content, "plain")
 }
 
 func parseSummaryContent(raw map[string]interface{}) []ContentBlock {
@@ -281,6 +337,15 @@ func parseUnknownContent(raw map[string]interface{}) []ContentBlock {
 
 // Render renders the message for display
 func (m *Message) Render(width int) string {
+	rendered, _ := m.RenderLayout(width, nil)
+	return rendered
+}
+
+// RenderLayout renders the message like Render and also returns the line
+// offset of each content block within the output (-1 for blocks that
+// rendered to nothing). display sets how each block is shown; nil expands
+// them all.
+func (m *Message) RenderLayout(width int, display []BlockDisplay) (string, []int) {
 	var b strings.Builder
 
 	// Badge with type
@@ -301,7 +366,7 @@ func (m *Message) Render(width int) string {
 		contentWidth = 20
 	}
 
-	content := m.renderContent(contentWidth)
+	content, offsets := m.renderContent(contentWidth, display)
 
 	// Apply meta style if this is a meta message
 	if m.IsMeta {
@@ -310,7 +375,14 @@ func (m *Message) Render(width int) string {
 
 	b.WriteString(content)
 
-	return b.String()
+	// Shift block offsets past the badge line
+	for i := range offsets {
+		if offsets[i] >= 0 {
+			offsets[i]++
+		}
+	}
+
+	return b.String(), offsets
 }
 
 func (m *Message) renderBadge() string {
@@ -319,69 +391,171 @@ func (m *Message) renderBadge() string {
 		label = fmt.Sprintf("%s (%s)", m.Type, m.Subtype)
 	}
 
+	// Tool results get their own marker next to the user badge
+	if m.Type == "user" && m.HasToolResult() {
+		return userBadgeStyle.Render("user") + " " + toolResultHeaderStyle.Render("tool_result")
+	}
+	return m.badgeStyle().Render(label)
+}
+
+// badgeStyle returns the badge style for the message's type
+func (m *Message) badgeStyle() lipgloss.Style {
 	switch m.Type {
 	case "user":
-		// Check if this is a tool_result
-		for _, block := range m.Content {
-			if block.Type == "tool_result" {
-				return userBadgeStyle.Render("user") + " " + toolResultHeaderStyle.Render("tool_result")
-			}
-		}
-		return userBadgeStyle.Render(label)
+		return userBadgeStyle
 	case "assistant":
-		return assistantBadgeStyle.Render(label)
+		return assistantBadgeStyle
 	case "system":
-		return systemBadgeStyle.Render(label)
+		return systemBadgeStyle
 	case "summary":
-		return summaryBadgeStyle.Render(label)
+		return summaryBadgeStyle
 	default:
-		return unknownBadgeStyle.Render(label)
+		return unknownBadgeStyle
 	}
 }
 
-func (m *Message) renderContent(width int) string {
+// HasToolResult returns whether the message carries a tool_result block
+func (m *Message) HasToolResult() bool {
+	for _, block := range m.Content {
+		if block.Type == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderContent renders the content blocks separated by blank lines, and
+// returns the line offset at which each block starts
+func (m *Message) renderContent(width int, display []BlockDisplay) (string, []int) {
 	var parts []string
+	offsets := make([]int, len(m.Content))
+	line := 0
 
-	for _, block := range m.Content {
-		rendered := renderBlock(block, width)
-		if rendered != "" {
-			parts = append(parts, rendered)
+	for i, block := range m.Content {
+		mode := BlockExpanded
+		if i < len(display) {
+			mode = display[i]
 		}
+		var rendered string
+		switch mode {
+		case BlockCollapsed:
+			rendered = renderBlockSummary(block, width)
+		case BlockExpanded:
+			rendered = renderBlock(block, width)
+		}
+		if rendered == "" {
+			offsets[i] = -1
+			continue
+		}
+		offsets[i] = line
+		line += strings.Count(rendered, "\n") + 2 // block lines plus the blank separator
+		parts = append(parts, rendered)
 	}
 
-	return strings.Join(parts, "\n\n")
+	return strings.Join(parts, "\n\n"), offsets
 }
 
 func renderBlock(block ContentBlock, width int) string {
 	switch block.Type {
 	case "text":
-		// Render as markdown
-		return renderMarkdown(block.Content, width)
+		// Markdown, code, diffs and so on each get their own renderer
+		return renderText(block.Content, width)
 
 	case "thinking":
 		// Render thinking in dimmed style
-		content := renderMarkdown(block.Content, width)
+		content := renderText(block.Content, width)
 		return thinkingStyle.Render("💭 Thinking:\n" + content)
 
 	case "tool_use":
-		// Show tool name and prettified input
-		header := toolUseHeaderStyle.Render("🔧 " + block.Name)
-		return header + "\n" + block.Content
+		// Tool-specific view, or the prettified input
+		return renderToolUse(block, width)
 
 	case "tool_result":
-		// Already prettified in parsing
+		// JSON is already prettified in parsing
 		header := toolResultHeaderStyle.Render("📤 Result")
-		return header + "\n" + block.Content
+		return header + "\n" + highlightToolResult(block, width)
 
 	case "plain":
-		// Plain text with word wrap
-		return wordwrap.String(block.Content, width)
+		return renderText(block.Content, width)
+
+	case blockCommand:
+		return renderCommand(block, width)
+
+	case blockCommandOutput:
+		return renderCommandOutput(block, width)
+
+	case blockReminder:
+		return renderReminder(block, width)
 
 	default:
 		return wordwrap.String(block.Content, width)
 	}
 }
 
+// renderBlockSummary renders a collapsed block as a single line: the first
+// line of thinking, the tool name and its key argument, or the size of a
+// tool result
+func renderBlockSummary(block ContentBlock, width int) string {
+	switch block.Type {
+	case "thinking":
+		return thinkingStyle.Render(truncateText("▸ 💭 "+firstLine(block.Content), width))
+
+	case "tool_use":
+		summary := "▸ 🔧 " + block.Name
+		if arg := toolKeyArgument(block.Content); arg != "" {
+			summary += "  " + arg
+		}
+		return toolUseHeaderStyle.Render(truncateText(summary, width))
+
+	case "tool_result":
+		lines := strings.Count(strings.TrimRight(block.Content, "\n"), "\n") + 1
+		label := "Result"
+		if block.IsError {
+			label = "Error"
+		}
+		plural := "s"
+		if lines == 1 {
+			plural = ""
+		}
+		return toolResultHeaderStyle.Render(fmt.Sprintf("▸ 📤 %s (%d line%s)", label, lines, plural))
+
+	case blockReminder:
+		return reminderSummary(block, width)
+
+	default:
+		return truncateText("▸ "+firstLine(block.Content), width)
+	}
+}
+
+// keyArgumentFields are the tool input fields that best identify a call, in
+// order of preference
+var keyArgumentFields = []string{
+	"command", "file_path", "notebook_path", "path", "pattern", "url", "query",
+	"description", "prompt", "skill",
+}
+
+// toolKeyArgument picks the most telling argument from a tool_use block's
+// JSON input, flattened to one line
+func toolKeyArgument(input string) string {
+	value, _ := keyArgument(input)
+	return firstLine(value)
+}
+
+// keyArgument returns the most telling argument from a tool_use block's JSON
+// input in full, if it has one
+func keyArgument(input string) (string, bool) {
+	var args map[string]interface{}
+	if json.Unmarshal([]byte(input), &args) != nil {
+		return "", false
+	}
+	for _, field := range keyArgumentFields {
+		if value, ok := args[field].(string); ok && strings.TrimSpace(value) != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
 // IsImplemented returns whether this message type has proper rendering
 func (m *Message) IsImplemented() bool {
 	return implementedTypes[m.Type]
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -124,3 +125,60 @@ func TestUnknownTypeWarning(t *testing.T) {
 		t.Error("Should render something for unknown types")
 	}
 }
+
+func TestRenderCollapsedBlocks(t *testing.T) {
+	msg := &Message{
+		Type: "assistant",
+		Content: []ContentBlock{
+			{Type: "thinking", Content: "\nFirst I should check the tests.\nThen fix them."},
+			{Type: "tool_use", Name: "Bash", Content: `{"command": "go test ./...", "description": "Run tests"}`},
+			{Type: "text", Content: "Done"},
+		},
+	}
+
+	rendered, offsets := msg.RenderLayout(80, []BlockDisplay{BlockCollapsed, BlockCollapsed, BlockExpanded})
+	plain := StripAnsi(rendered)
+	if !strings.Contains(plain, "▸ 💭 First I should check the tests.") {
+		t.Errorf("Collapsed thinking should show its first line:\n%s", plain)
+	}
+	if strings.Contains(plain, "Then fix them") {
+		t.Error("Collapsed thinking should hide the rest")
+	}
+	if !strings.Contains(plain, "▸ 🔧 Bash  go test ./...") {
+		t.Errorf("Collapsed tool call should show the command:\n%s", plain)
+	}
+	// Badge, summary, blank, summary, blank, text
+	if offsets[0] != 1 || offsets[1] != 3 || offsets[2] != 5 {
+		t.Errorf("offsets = %v, want [1 3 5]", offsets)
+	}
+
+	result := renderBlockSummary(ContentBlock{Type: "tool_result", Content: "a\nb\nc\n", IsError: true}, 80)
+	if got := StripAnsi(result); got != "▸ 📤 Error (3 lines)" {
+		t.Errorf("tool result summary = %q", got)
+	}
+}
+
+func TestPairToolResults(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.jsonl")
+
+	testData := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Read","input":{"file_path":"/src/main.go"}}]},"uuid":"a-1"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"     1\tpackage main"}]},"uuid":"u-1"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_2","content":"orphan"}]},"uuid":"u-2"}`
+
+	if err := os.WriteFile(testFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	messages, err := ParseJSONLMessages(testFile)
+	if err != nil {
+		t.Fatalf("ParseJSONLMessages failed: %v", err)
+	}
+
+	result := messages[1].Content[0]
+	if result.Tool != "Read" || result.Path != "/src/main.go" {
+		t.Errorf("Result paired with %q %q, want Read /src/main.go", result.Tool, result.Path)
+	}
+	if orphan := messages[2].Content[0]; orphan.Tool != "" || orphan.Path != "" {
+		t.Errorf("Unpaired result got %q %q", orphan.Tool, orphan.Path)
+	}
+}
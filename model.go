@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -14,6 +15,7 @@ type State int
 const (
 	StateFileList State = iota
 	StateViewer
+	StateSessionSearch
 )
 
 type ViewMode int
@@ -28,15 +30,34 @@ type Model struct {
 	files       []FileInfo
 	fileIndex   int
 	projectPath string // Original project path (if viewing history for a project)
+	sessionPath string // Session file open in the viewer
+	returnState State  // Where leaving the viewer goes back to
 
 	// Content - JSON mode
-	rawLines         []string // Raw JSON lines (for searching/preview)
-	highlightedLines []string // Syntax-highlighted lines
+	rawLines             []string     // Displayed raw JSON lines (for searching/preview)
+	highlightedLines     []string     // Displayed syntax-highlighted lines
+	fullRawLines         []string     // Every raw JSON line
+	fullHighlightedLines []string     // Every syntax-highlighted line
+	lineOrigin           []int        // Full line of each displayed line (nil when all are shown)
+	lineEnd              []int        // End of the full lines each displayed line stands for (folds span several)
+	records              []jsonRecord // JSONL records within fullRawLines
+	jsonRoots            []*jsonNode  // Tree of each record (nil if it isn't JSON)
 
 	// Content - Message mode
-	messages           []Message // Parsed messages
-	renderedThread     []string  // Pre-rendered thread lines
-	threadScrollOffset int       // Scroll position in thread
+	messages           []Message             // Parsed messages
+	renderedThread     []string              // Pre-rendered thread lines
+	layout             threadLayout          // Message/block positions in renderedThread
+	threadScrollOffset int                   // Scroll position in thread
+	msgCursor          int                   // Selected message
+	msgRecord          []int                 // Index into records of each message
+	foldStates         map[string]*foldState // Collapsed blocks, by session path
+	outline            []outlineTurn         // Turns of the session
+	showOutline        bool                  // Show the outline sidebar
+	showSplit          bool                  // Show the selected message's raw JSON beside the thread
+	showBookmarks      bool                  // Show the bookmark list in the sidebar, in place of the outline
+	bookmarks          *Bookmarks            // Sidecar bookmark store (nil when unavailable)
+	bookmarksErr       error                 // Why bookmarks are unavailable
+	tempFiles          []string              // Written for the pager or editor, removed on exit
 
 	// View mode
 	viewMode ViewMode
@@ -45,13 +66,64 @@ type Model struct {
 	cursorLine   int // Current line (0-indexed)
 	scrollOffset int // First visible line
 
+	// Preview pane (JSON mode)
+	jsonPaneSplit  int            // JSON pane's share of the width in percent (0 for the default)
+	previewFocus   bool           // Keys scroll and search the preview
+	previewFull    bool           // Preview shown full-screen
+	previewScroll  int            // First body line shown
+	previewAt      int            // Full line previewScroll belongs to
+	previewQuery   string         // Search within the preview
+	previewPattern *SearchPattern // Compiled previewQuery
+	previewErr     string         // Error from compiling previewQuery
+	previewCache   *previewCache  // Rendered preview, shared by the copies of the model
 
 	// Shared state
-	searchQuery string
-	searchInput string
-	searchMode  bool
-	numBuffer   string // For vim number prefix (e.g., "10" in "10j")
-	lastKey     string // Track last key for "gg" detection
+	searchQuery      string
+	searchInput      string
+	searchMode       bool
+	searchPrompt     string         // "/" while typing a search, "&" a filter, "|" a path, "note: " a bookmark note
+	searchOpts       SearchOptions  // Regex / case / whole-word toggles
+	searchPattern    *SearchPattern // Compiled searchQuery (nil if empty or invalid)
+	searchStructured *Query         // Parsed searchQuery when it uses field:value syntax
+	searchErr        string         // Error from compiling searchQuery
+	searchHits       []int          // Line of every match in the current mode's lines
+	numBuffer        string         // For vim number prefix (e.g., "10" in "10j")
+	lastKey          string         // Track last key for "gg" detection
+
+	// Message filter
+	filterQuery string
+	filter      *Query                // Hides non-matching messages in Message mode (nil shows all)
+	hiddenKinds [numContentKinds]bool // Message mode show/hide toggles
+
+	// Path query (JSON mode)
+	pathQueryText string
+	pathQuery     *PathQuery
+	pathHits      []PathHit // Sorted by line in the full content
+	pathOnly      bool      // Show only records with hits
+
+	statusMessage string // One-shot footer message, cleared on the next key
+
+	// Redaction
+	redactor       *Redactor
+	redact         bool      // Mask secrets in the viewer
+	exportRedactor *Redactor // Redactor for exports (nil when disabled)
+	jsonContent    string    // Unredacted pretty-printed JSON
+	parsedMessages []Message // Unredacted messages
+
+	// Settings from the config file
+	keys         KeysConfig
+	startInJSON  bool                    // Sessions open in JSON mode rather than Message mode
+	foldDefaults map[string]bool         // Collapse-all toggles sessions start with (nil for the built-in ones)
+	pricing      map[string]ModelPricing // By model ID prefix (nil for the list prices)
+	cost         float64                 // Estimated cost of the open session in USD
+	unpriced     []string                // Models of the open session missing from the pricing
+
+	// Cross-session search
+	index         *Index
+	indexPath     string // On-disk index location ("" disables indexing)
+	indexing      bool
+	indexErr      error
+	sessionSearch sessionSearch
 
 	// Dimensions
 	width  int
@@ -78,6 +150,9 @@ var (
 	searchStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("214"))
 
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("114"))
+
 	cursorLineStyle = lipgloss.NewStyle().
 			Background(lipgloss.Color("236"))
 
@@ -86,6 +161,13 @@ var (
 			Width(6).
 			Align(lipgloss.Right)
 
+	messageCursorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("39"))
+
+	modeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39")).
+			Bold(true)
+
 	lineNumberSelectedStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("214")).
 				Width(6).
@@ -93,16 +175,39 @@ var (
 				Bold(true)
 )
 
-func NewModel(files []FileInfo, projectPath string) Model {
-	return Model{
+func NewModel(files []FileInfo, projectPath string, cfg Config, redactor *Redactor) Model {
+	m := Model{
 		state:       StateFileList,
 		files:       files,
 		fileIndex:   0,
 		projectPath: projectPath,
+		redactor:    redactor,
+		redact:      cfg.Redaction.Viewer,
+
+		keys:         cfg.Keys,
+		startInJSON:  viewModeNames[cfg.Defaults.ViewMode] == ViewModeJSON,
+		foldDefaults: cfg.Defaults.Collapsed,
+		pricing:      cfg.Pricing,
+	}
+	if path, err := DefaultIndexPath(); err == nil {
+		m.indexPath = path
+		m.indexing = true // Init starts the first update
+	}
+	if cfg.Redaction.Exports {
+		m.exportRedactor = redactor
+	}
+	if path, err := DefaultBookmarksPath(); err != nil {
+		m.bookmarksErr = err
+	} else if m.bookmarks, err = LoadBookmarks(path); err != nil {
+		m.bookmarks, m.bookmarksErr = nil, err // Don't overwrite a file we can't read
 	}
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
+	if m.indexing {
+		return updateIndexCmd(m.indexPath, m.redactor)
+	}
 	return nil
 }
 
@@ -120,6 +225,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleFileListKeys(msg)
 		case StateViewer:
 			return m.handleViewerKeys(msg)
+		case StateSessionSearch:
+			return m.handleSessionSearchKeys(msg)
+		}
+
+	case externalDoneMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("%s failed: %v", msg.program, msg.err)
+		}
+
+	case clipboardMsg:
+		if msg.err != nil {
+			m.statusMessage = "Copy failed: " + msg.err.Error()
+		} else {
+			m.statusMessage = fmt.Sprintf("Copied %s (%s)", msg.label, formatSize(msg.size))
+		}
+
+	case indexUpdatedMsg:
+		m.indexing = false
+		m.indexErr = msg.err
+		if msg.index != nil {
+			m.index = msg.index
+		}
+		if m.state == StateSessionSearch {
+			m.runSessionSearch()
 		}
 
 	case tea.WindowSizeMsg:
@@ -128,7 +257,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ready = true
 		// Re-render thread if in message mode with messages loaded
 		if m.state == StateViewer && m.viewMode == ViewModeMessage && len(m.messages) > 0 {
-			m.renderedThread = m.renderThread(m.width - 2)
+			m.renderThread(m.threadWidth())
+			m.refreshSearch()
 		}
 	}
 
@@ -136,7 +266,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleFileListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
+	key := remapKey(m.keys.FileList, msg.String())
+	switch key {
 	case "q", "ctrl+c":
 		return m, tea.Quit
 
@@ -152,36 +283,16 @@ func (m Model) handleFileListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "enter":
 		if len(m.files) > 0 {
-			filePath := m.files[m.fileIndex].Path
-
-			// Parse for JSON mode
-			content, err := ParseJSONLFile(filePath)
-			if err != nil {
+			if err := m.openSession(m.files[m.fileIndex].Path); err != nil {
 				m.err = err
 				return m, nil
 			}
-			m.rawLines = strings.Split(content, "\n")
-			highlighted := HighlightJSON(content)
-			m.highlightedLines = strings.Split(highlighted, "\n")
-
-			// Parse for Message mode
-			messages, err := ParseJSONLMessages(filePath)
-			if err != nil {
-				m.err = err
-				return m, nil
-			}
-			m.messages = messages
-			m.renderedThread = m.renderThread(m.width - 2)
-
-			// Reset state
-			m.cursorLine = 0
-			m.scrollOffset = 0
-			m.threadScrollOffset = 0
-			m.viewMode = ViewModeMessage // Start in message mode
-			m.state = StateViewer
-			m.searchQuery = ""
+			m.returnState = StateFileList
 		}
 
+	case "S":
+		return m, m.openSessionSearch()
+
 	case "g":
 		if m.lastKey == "g" {
 			m.fileIndex = 0
@@ -195,22 +306,127 @@ func (m Model) handleFileListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.fileIndex = len(m.files) - 1
 	}
 
-	if msg.String() != "g" {
+	if key != "g" {
 		m.lastKey = ""
 	}
 
 	return m, nil
 }
 
+// openSession loads a session file and switches to the viewer
+func (m *Model) openSession(filePath string) error {
+	if err := m.loadSession(filePath); err != nil {
+		return err
+	}
+
+	// Reset state
+	m.cursorLine = 0
+	m.scrollOffset = 0
+	m.threadScrollOffset = 0
+	m.msgCursor = 0
+	m.viewMode = ViewModeMessage
+	if m.startInJSON {
+		m.viewMode = ViewModeJSON
+	}
+	m.pathOnly = false
+	m.clearPathQuery()
+	m.previewFocus = false
+	m.previewFull = false
+	m.applyPreviewSearch("")
+	m.state = StateViewer
+	m.clearSearch()
+	if m.filter != nil {
+		m.filterQuery = ""
+		m.filter = nil
+		m.renderThread(m.threadWidth())
+	}
+	return nil
+}
+
+// loadSession parses a session file for both view modes
+func (m *Model) loadSession(filePath string) error {
+	// Parse for JSON mode
+	content, err := ParseJSONLFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	// Parse for Message mode
+	messages, err := ParseJSONLMessages(filePath)
+	if err != nil {
+		return err
+	}
+
+	m.sessionPath = filePath
+	m.jsonContent = content
+	m.parsedMessages = messages
+	m.updateCost()
+	m.refreshContent()
+	return nil
+}
+
+// refreshContent rebuilds the displayed lines and messages from the parsed
+// session, applying redaction if it is enabled
+func (m *Model) refreshContent() {
+	content := m.jsonContent
+	messages := m.parsedMessages
+	if m.redact {
+		content = m.redactor.Redact(content)
+		messages = m.redactor.RedactMessages(messages)
+	}
+
+	m.fullRawLines = strings.Split(content, "\n")
+	m.previewCache = &previewCache{}
+	m.records = splitRecords(m.fullRawLines)
+	m.jsonRoots = buildJSONTrees(m.records)
+	m.fullHighlightedLines = renderJSONLines(m.fullRawLines, m.records, m.jsonRoots)
+	m.msgRecord = messageRecords(m.records)
+
+	m.messages = messages
+	m.outline = buildOutline(messages)
+	m.renderThread(m.threadWidth())
+	m.refreshPathQuery() // Also refreshes the search
+}
+
 func (m Model) handleViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Remaps apply to the first key of a command; the second key of a
+	// prefix command (za, yy, gg, gf) is taken as typed
 	key := msg.String()
+	second := m.lastKey == "y" || m.lastKey == "z" || m.lastKey == "f" ||
+		m.lastKey == "g" && (key == "g" || key == "f")
+	if !second {
+		key = remapKey(m.keys.Viewer, key)
+	}
+	m.statusMessage = ""
+	if key == "" {
+		m.lastKey = ""
+		return m, nil // Disabled in the config
+	}
 
 	// Tab toggles view mode
 	if key == "tab" {
-		if m.viewMode == ViewModeJSON {
-			m.viewMode = ViewModeMessage
-		} else {
-			m.viewMode = ViewModeJSON
+		m.toggleViewMode()
+		return m, nil
+	}
+
+	// Second key of a "y" yank command
+	if m.lastKey == "y" {
+		m.lastKey = ""
+		return m, m.handleYankKey(key)
+	}
+
+	// Second key of a "z" fold or "f" kind toggle command
+	if m.lastKey == "z" || m.lastKey == "f" {
+		prefix := m.lastKey
+		m.lastKey = ""
+		switch {
+		case prefix == "z" && m.viewMode == ViewModeJSON:
+			m.handleJSONFoldKey(key)
+		case m.viewMode == ViewModeJSON:
+		case prefix == "z":
+			m.handleFoldKey(key)
+		default:
+			m.handleKindToggleKey(key)
 		}
 		return m, nil
 	}
@@ -224,6 +440,7 @@ func (m Model) handleViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.ensureCursorVisible()
 			} else {
 				m.threadScrollOffset = 0
+				m.followScroll()
 			}
 			return m, nil
 		}
@@ -240,22 +457,31 @@ func (m Model) handleViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.numBuffer = ""
 	}
 
+	if m.previewFocus && m.viewMode == ViewModeJSON && m.handlePreviewKeys(key, count) {
+		return m, nil
+	}
+
 	switch key {
 	case "q":
-		m.state = StateFileList
-		m.searchQuery = ""
-		m.searchInput = ""
+		m.state = m.returnState
+		m.clearSearch()
 		return m, nil
 
 	case "esc":
 		if m.searchQuery != "" {
-			m.searchQuery = ""
-			m.searchInput = ""
+			m.clearSearch()
+			return m, nil
+		}
+		if m.pathQuery != nil && m.viewMode == ViewModeJSON {
+			m.clearPathQuery()
 			return m, nil
 		}
-		m.state = StateFileList
+		m.state = m.returnState
 		return m, nil
 
+	case "S":
+		return m, m.openSessionSearch()
+
 	case "ctrl+c":
 		return m, tea.Quit
 
@@ -296,6 +522,7 @@ func (m Model) handleViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.scrollOffset = 0
 			} else {
 				m.threadScrollOffset = 0
+				m.followScroll()
 			}
 			m.lastKey = ""
 		} else {
@@ -303,6 +530,14 @@ func (m Model) handleViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "z", "f", "y":
+		if key == "f" && m.lastKey == "g" && m.viewMode == ViewModeMessage {
+			m.lastKey = ""
+			return m, m.openToolFile()
+		}
+		m.lastKey = key
+		return m, nil
+
 	case "G":
 		if m.viewMode == ViewModeJSON {
 			m.cursorLine = len(m.rawLines) - 1
@@ -314,22 +549,195 @@ func (m Model) handleViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				maxScroll = 0
 			}
 			m.threadScrollOffset = maxScroll
+			m.followScroll()
+		}
+
+	case "E":
+		m.exportCurrentHTML()
+
+	case "M":
+		m.exportCurrentMarkdown()
+
+	case "R":
+		m.redact = !m.redact
+		m.refreshContent()
+		if m.redact {
+			m.statusMessage = "Redaction on"
+		} else {
+			m.statusMessage = "Redaction off"
 		}
 
 	case "/":
 		m.searchMode = true
+		m.searchPrompt = "/"
 		m.searchInput = ""
 		return m, nil
 
-	case "n":
-		if m.searchQuery != "" {
-			m.findNext(1)
+	case "&":
+		m.searchMode = true
+		m.searchPrompt = "&"
+		m.searchInput = m.filterQuery
+		return m, nil
+
+	case "|":
+		if m.viewMode != ViewModeJSON {
+			m.statusMessage = "Path queries work in JSON mode (Tab)"
+			return m, nil
+		}
+		m.searchMode = true
+		m.searchPrompt = "|"
+		m.searchInput = m.pathQueryText
+		return m, nil
+
+	case "}":
+		if m.viewMode == ViewModeJSON {
+			m.findNextPathHit(1)
+		} else {
+			m.moveMessageCursor(count, 1, true)
 		}
 
-	case "N":
-		if m.searchQuery != "" {
-			m.findNext(-1)
+	case "{":
+		if m.viewMode == ViewModeJSON {
+			m.findNextPathHit(-1)
+		} else {
+			m.moveMessageCursor(count, -1, true)
+		}
+
+	case "J":
+		if m.viewMode == ViewModeMessage {
+			m.moveMessageCursor(count, 1, false)
+		}
+
+	case "K":
+		if m.viewMode == ViewModeMessage {
+			m.moveMessageCursor(count, -1, false)
+		}
+
+	case "ctrl+e":
+		if m.viewMode == ViewModeMessage {
+			m.scrollWithinMessage(count, 1)
+		}
+
+	case "ctrl+y":
+		if m.viewMode == ViewModeMessage {
+			m.scrollWithinMessage(count, -1)
+		}
+
+	case "ctrl+f":
+		if m.viewMode == ViewModeMessage {
+			m.scrollWithinMessage(m.viewerHeight()-2, 1)
+		}
+
+	case "ctrl+b":
+		if m.viewMode == ViewModeMessage {
+			m.scrollWithinMessage(m.viewerHeight()-2, -1)
+		}
+
+	case "t":
+		if m.viewMode == ViewModeMessage {
+			m.toggleOutline()
+		}
+		return m, nil
+
+	case "m":
+		if m.viewMode == ViewModeMessage {
+			m.toggleBookmark()
+		}
+		return m, nil
+
+	case "v", "e":
+		if m.viewMode == ViewModeMessage {
+			return m, m.openMessageExternally(key == "e")
+		}
+		return m, nil
+
+	case "V":
+		if m.viewMode == ViewModeMessage {
+			return m, m.openSessionExternally()
+		}
+		return m, nil
+
+	case "a":
+		if m.viewMode == ViewModeMessage {
+			m.startAnnotation()
 		}
+		return m, nil
+
+	case "'":
+		if m.viewMode == ViewModeMessage {
+			m.toggleBookmarkList()
+		}
+		return m, nil
+
+	case ")":
+		if m.viewMode == ViewModeMessage {
+			m.moveBookmark(count, 1)
+		}
+		return m, nil
+
+	case "(":
+		if m.viewMode == ViewModeMessage {
+			m.moveBookmark(count, -1)
+		}
+		return m, nil
+
+	case "p":
+		if m.viewMode == ViewModeJSON {
+			m.togglePreviewFocus()
+		}
+		return m, nil
+
+	case "P":
+		if m.viewMode == ViewModeJSON {
+			m.togglePreviewFull()
+		}
+		return m, nil
+
+	case ">":
+		if m.viewMode == ViewModeJSON {
+			m.resizePanes(count, 1)
+		}
+		return m, nil
+
+	case "<":
+		if m.viewMode == ViewModeJSON {
+			m.resizePanes(count, -1)
+		}
+		return m, nil
+
+	case "s":
+		if m.viewMode == ViewModeMessage {
+			m.toggleSplit()
+		}
+		return m, nil
+
+	case "]":
+		if m.viewMode == ViewModeMessage {
+			m.moveTurn(count, 1)
+		}
+		return m, nil
+
+	case "[":
+		if m.viewMode == ViewModeMessage {
+			m.moveTurn(count, -1)
+		}
+		return m, nil
+
+	case "o":
+		if m.viewMode == ViewModeMessage {
+			m.openRawMessage()
+		}
+
+	case "F":
+		if m.viewMode == ViewModeJSON {
+			m.togglePathOnly()
+		}
+
+	case "n":
+		m.findNext(1)
+
+	case "N":
+		m.findNext(-1)
 	}
 
 	if key != "g" {
@@ -339,6 +747,30 @@ func (m Model) handleViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// exportCurrentHTML writes an HTML export of the open session into the
+// working directory
+func (m *Model) exportCurrentHTML() {
+	sessionPath := m.sessionPath
+	outPath := sessionTitle(sessionPath) + ".html"
+	if err := ExportHTMLFile(sessionPath, outPath, m.exportRedactor, m.bookmarks); err != nil {
+		m.statusMessage = "Export failed: " + err.Error()
+		return
+	}
+	m.statusMessage = "Exported " + outPath
+}
+
+// exportCurrentMarkdown writes a Markdown export of the open session into the
+// working directory
+func (m *Model) exportCurrentMarkdown() {
+	sessionPath := m.sessionPath
+	outPath := sessionTitle(sessionPath) + ".md"
+	if err := ExportMarkdownFile(sessionPath, outPath, m.exportRedactor, m.bookmarks); err != nil {
+		m.statusMessage = "Export failed: " + err.Error()
+		return
+	}
+	m.statusMessage = "Exported " + outPath
+}
+
 func (m *Model) handleJSONNavigation(count, direction int) {
 	totalLines := len(m.rawLines)
 	m.cursorLine += count * direction
@@ -370,15 +802,140 @@ func (m *Model) handleMessageNavigation(count, direction int) {
 	if m.threadScrollOffset < 0 {
 		m.threadScrollOffset = 0
 	}
+	m.followScroll()
+}
+
+// messageAtLine returns the message a rendered thread line belongs to,
+// counting separators as part of the next message, or -1
+func (m Model) messageAtLine(line int) int {
+	for ; line >= 0 && line < len(m.layout.lineBlock); line++ {
+		if ref := m.layout.lineBlock[line]; ref.Msg >= 0 {
+			return ref.Msg
+		}
+	}
+	return -1
+}
+
+// followScroll moves the message cursor to the top visible message once the
+// selected one has scrolled out of view
+func (m *Model) followScroll() {
+	if m.msgCursor < len(m.layout.msgStart) && m.layout.msgStart[m.msgCursor] >= 0 &&
+		m.layout.msgStart[m.msgCursor] < m.threadScrollOffset+m.viewerHeight() &&
+		m.layout.msgEnd[m.msgCursor] > m.threadScrollOffset {
+		return
+	}
+	if i := m.messageAtLine(m.threadScrollOffset); i >= 0 {
+		m.msgCursor = i
+	}
+}
+
+// selectMessage moves the message cursor to message i, scrolling its start to
+// the top unless the whole message is already on screen
+func (m *Model) selectMessage(i int) {
+	if i < 0 || i >= len(m.layout.msgStart) || m.layout.msgStart[i] < 0 {
+		return
+	}
+	m.msgCursor = i
+	start, end := m.layout.msgStart[i], m.layout.msgEnd[i]
+	if start < m.threadScrollOffset || end > m.threadScrollOffset+m.viewerHeight() {
+		m.threadScrollOffset = start
+	}
+	m.handleMessageNavigation(0, 1) // Clamp
+}
+
+// moveMessageCursor selects the count-th visible message after (direction >
+// 0) or before the current one. With sameRole only messages of the current
+// message's role count, so tool results don't interrupt a walk over prompts.
+func (m *Model) moveMessageCursor(count, direction int, sameRole bool) {
+	if m.msgCursor >= len(m.messages) {
+		return
+	}
+	role := indexRole(&m.messages[m.msgCursor])
+	target := m.msgCursor
+	for i := m.msgCursor + direction; i >= 0 && i < len(m.messages) && count > 0; i += direction {
+		if m.layout.msgStart[i] < 0 || (sameRole && indexRole(&m.messages[i]) != role) {
+			continue
+		}
+		target = i
+		count--
+	}
+	m.selectMessage(target)
+}
+
+// scrollWithinMessage scrolls by count lines without leaving the selected
+// message: the view stops at the message's first line and at the point where
+// its last line reaches the bottom
+func (m *Model) scrollWithinMessage(count, direction int) {
+	if m.msgCursor >= len(m.layout.msgStart) || m.layout.msgStart[m.msgCursor] < 0 {
+		return
+	}
+	start, end := m.layout.msgStart[m.msgCursor], m.layout.msgEnd[m.msgCursor]
+	maxOffset := end - m.viewerHeight()
+	if maxOffset < start {
+		maxOffset = start
+	}
+
+	offset := m.threadScrollOffset + count*direction
+	if offset < start {
+		offset = start
+	}
+	if offset > maxOffset {
+		offset = maxOffset
+	}
+	m.threadScrollOffset = offset
+	m.handleMessageNavigation(0, 1) // Clamp
+}
+
+// messageRecords maps each message to the JSONL record it was parsed from.
+// ParseJSONLMessages keeps every JSON object except file history snapshots,
+// so messages line up with those records in order.
+func messageRecords(records []jsonRecord) []int {
+	var indexes []int
+	for r, rec := range records {
+		obj, ok := rec.Value.(map[string]interface{})
+		if !rec.OK || !ok {
+			continue
+		}
+		if t, _ := obj["type"].(string); t == "file-history-snapshot" {
+			continue
+		}
+		indexes = append(indexes, r)
+	}
+	return indexes
+}
+
+// openRawMessage switches to JSON mode with the selected message's record at
+// the top
+func (m *Model) openRawMessage() {
+	if m.msgCursor >= len(m.msgRecord) {
+		m.statusMessage = "No raw record for this message"
+		return
+	}
+	m.viewMode = ViewModeJSON
+	m.syncToJSON()
+	m.refreshSearch()
 }
 
 func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
-		m.searchQuery = m.searchInput
 		m.searchMode = false
-		if m.searchQuery != "" {
-			m.findNext(1)
+		switch m.searchPrompt {
+		case "&":
+			m.applyFilter(m.searchInput)
+			m.searchInput = ""
+		case "|":
+			m.applyPathQuery(m.searchInput)
+			m.searchInput = ""
+		case "note: ":
+			m.annotate(m.searchInput)
+			m.searchInput = ""
+		default:
+			if m.previewFocus && m.viewMode == ViewModeJSON {
+				m.applyPreviewSearch(m.searchInput)
+			} else {
+				m.applySearch(m.searchInput)
+			}
 		}
 		return m, nil
 
@@ -388,19 +945,234 @@ func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "backspace":
-		if len(m.searchInput) > 0 {
-			m.searchInput = m.searchInput[:len(m.searchInput)-1]
-		}
+		m.searchInput = trimLastRune(m.searchInput)
+		return m, nil
+
+	case "alt+r":
+		m.searchOpts.Regex = !m.searchOpts.Regex
+		return m, nil
+
+	case "alt+c":
+		m.searchOpts.Case = (m.searchOpts.Case + 1) % 3
+		return m, nil
+
+	case "alt+w":
+		m.searchOpts.WholeWord = !m.searchOpts.WholeWord
+		return m, nil
+
+	case "alt+s":
+		m.searchOpts.Scope = (m.searchOpts.Scope + 1) % numSearchScopes
 		return m, nil
 
 	default:
-		if len(msg.String()) == 1 {
+		if m.searchPrompt == "note: " {
+			// Notes are free text, so take any characters typed or pasted
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				m.searchInput += string(msg.Runes)
+			}
+		} else if len(msg.String()) == 1 {
 			m.searchInput += msg.String()
 		}
 		return m, nil
 	}
 }
 
+// applySearch compiles query with the current options and jumps to the first
+// match. Queries using field:value syntax match whole messages instead.
+// Invalid regexes and queries are reported in the footer.
+func (m *Model) applySearch(query string) {
+	m.searchQuery = query
+	m.searchPattern = nil
+	m.searchStructured = nil
+	m.searchErr = ""
+	m.searchHits = nil
+	if query == "" {
+		return
+	}
+
+	if LooksLikeQuery(query) {
+		q, err := ParseQuery(query)
+		if err != nil {
+			m.searchErr = "Invalid query: " + err.Error()
+			return
+		}
+		m.searchStructured = q
+		m.searchPattern = q.HighlightPattern()
+		m.refreshSearch()
+		m.findNext(1)
+		return
+	}
+
+	pattern, err := CompileSearch(query, m.searchOpts)
+	if err != nil {
+		m.searchErr = "Invalid regex: " + err.Error()
+		return
+	}
+	m.searchPattern = pattern
+	m.refreshSearch()
+	m.findNext(1)
+}
+
+// clearSearch drops the active search
+func (m *Model) clearSearch() {
+	m.searchQuery = ""
+	m.searchInput = ""
+	m.searchPattern = nil
+	m.searchStructured = nil
+	m.searchErr = ""
+	m.searchHits = nil
+}
+
+// applyFilter hides messages that don't match query. An empty query shows
+// every message again.
+func (m *Model) applyFilter(query string) {
+	var filter *Query
+	if strings.TrimSpace(query) != "" {
+		q, err := ParseQuery(query)
+		if err != nil {
+			m.statusMessage = "Invalid filter: " + err.Error()
+			return
+		}
+		filter = q
+	} else {
+		query = ""
+	}
+
+	m.filterQuery = query
+	m.filter = filter
+	m.rerenderAnchored(blockRef{Msg: m.msgCursor, Block: -1})
+}
+
+// messageVisible reports whether message i passes the filter and the kind
+// toggles
+func (m Model) messageVisible(i int) bool {
+	return (m.filter == nil || m.filter.Match(&m.messages[i])) && m.kindVisible(i)
+}
+
+// visiblePosition returns the 1-based position of message i among the
+// messages passing the filter
+func (m Model) visiblePosition(i int) int {
+	n := 0
+	for j := 0; j <= i && j < len(m.messages); j++ {
+		if m.messageVisible(j) {
+			n++
+		}
+	}
+	return n
+}
+
+// visibleMessageCount returns the number of messages passing the filter
+func (m Model) visibleMessageCount() int {
+	n := 0
+	for i := range m.messages {
+		if m.messageVisible(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// refreshSearch recomputes match positions after the searched lines change
+func (m *Model) refreshSearch() {
+	m.searchHits = nil
+	if m.searchStructured != nil {
+		// Structured queries hit whole messages, so only Message mode has hits
+		if m.viewMode == ViewModeMessage {
+			for i := range m.messages {
+				if m.layout.msgStart[i] >= 0 && m.searchStructured.Match(&m.messages[i]) {
+					m.searchHits = append(m.searchHits, m.layout.msgStart[i])
+				}
+			}
+		}
+		return
+	}
+	if m.searchPattern == nil {
+		return
+	}
+	if m.viewMode == ViewModeMessage {
+		m.searchHits = messageSearchHits(m.messages, m.renderedThread, m.layout, m.searchPattern, m.searchOpts.Scope)
+	} else {
+		m.searchHits = m.jsonSearchHits()
+	}
+}
+
+// jsonSearchHits returns the displayed line of every match in the JSON. The
+// full lines are searched, so matches inside a fold land on the fold, and
+// matches in hidden records are left out.
+func (m Model) jsonSearchHits() []int {
+	var hits []int
+	for _, line := range searchHitLines(m.fullRawLines, m.searchPattern) {
+		i := m.displayLine(line)
+		if m.lineOrigin != nil && (i < 0 || line < m.lineOrigin[i] || line >= m.lineEnd[i]) {
+			continue
+		}
+		hits = append(hits, i)
+	}
+	return hits
+}
+
+// lineInSearchScope reports whether a rendered thread line belongs to a
+// content block that the current search covers
+func (m Model) lineInSearchScope(line int) bool {
+	if line >= len(m.layout.lineBlock) {
+		return false
+	}
+	ref := m.layout.lineBlock[line]
+	if ref.Msg < 0 || ref.Block < 0 {
+		return false
+	}
+	msg := &m.messages[ref.Msg]
+	if m.searchStructured != nil {
+		return m.searchStructured.Match(msg)
+	}
+	return m.searchOpts.Scope.Includes(msg, msg.Content[ref.Block])
+}
+
+// searchIndicator renders the header's search status, e.g. "[/foo 3/17]"
+func (m Model) searchIndicator() string {
+	if m.searchQuery == "" {
+		return ""
+	}
+	if m.searchErr != "" {
+		return "  " + warningStyle.Render(fmt.Sprintf("[/%s invalid]", m.searchQuery))
+	}
+
+	current := m.cursorLine
+	if m.viewMode == ViewModeMessage {
+		current = m.threadScrollOffset
+	}
+	pos := "-"
+	if n := hitPosition(m.searchHits, current); n > 0 {
+		pos = strconv.Itoa(n)
+	}
+	return "  " + searchStyle.Render(fmt.Sprintf("[/%s %s/%d]", m.searchQuery, pos, len(m.searchHits)))
+}
+
+// searchFooter renders the footer while a search is being typed or after it
+// failed. ok is false when the regular footer should be shown.
+func (m Model) searchFooter() (string, bool) {
+	if m.searchMode {
+		prompt := searchStyle.Render(m.searchPrompt + m.searchInput)
+		switch m.searchPrompt {
+		case "&":
+			return prompt + helpStyle.Render("  filter, e.g. role:assistant tool:Bash is:error • empty: show all"), true
+		case "|":
+			return prompt + helpStyle.Render(`  path, e.g. .message.usage.output_tokens, .message.content[].name == "Bash" • empty: close`), true
+		case "note: ":
+			return prompt + helpStyle.Render("  enter: save • empty: bookmark without a note • esc: cancel"), true
+		}
+		opts := helpStyle.Render(fmt.Sprintf("  [%s]  alt+r: regex • alt+c: case • alt+w: word • alt+s: scope", m.searchOpts))
+		return prompt + opts, true
+	}
+	if m.searchErr != "" {
+		return warningStyle.Render(m.searchErr), true
+	}
+	if m.previewErr != "" && m.previewFocus {
+		return warningStyle.Render(m.previewErr), true
+	}
+	return "", false
+}
+
 // viewerHeight returns the number of visible lines in the viewer
 func (m Model) viewerHeight() int {
 	return m.height - 5 // header + divider + footer + padding
@@ -408,7 +1180,7 @@ func (m Model) viewerHeight() int {
 
 // leftPaneWidth returns the width of the JSON pane (left side)
 func (m Model) leftPaneWidth() int {
-	return m.width * 55 / 100 // 55% for JSON
+	return m.width * m.jsonPanePercent() / 100
 }
 
 // rightPaneWidth returns the width of the preview pane (right side)
@@ -435,48 +1207,23 @@ func (m *Model) ensureCursorVisible() {
 }
 
 func (m *Model) findNext(direction int) {
-	if m.searchQuery == "" {
+	if m.searchPattern == nil && m.searchStructured == nil {
 		return
 	}
 
-	queryLower := strings.ToLower(m.searchQuery)
-
 	if m.viewMode == ViewModeMessage {
 		// Search in rendered thread
-		totalLines := len(m.renderedThread)
-		if totalLines == 0 {
-			return
-		}
-
-		for i := 1; i <= totalLines; i++ {
-			var lineIdx int
-			if direction > 0 {
-				lineIdx = (m.threadScrollOffset + i) % totalLines
-			} else {
-				lineIdx = (m.threadScrollOffset - i + totalLines) % totalLines
-			}
-
-			if strings.Contains(strings.ToLower(m.renderedThread[lineIdx]), queryLower) {
-				m.threadScrollOffset = lineIdx
-				return
+		if line, ok := nextHitLine(m.searchHits, m.threadScrollOffset, direction); ok {
+			m.threadScrollOffset = line
+			if i := m.messageAtLine(line); i >= 0 {
+				m.msgCursor = i
 			}
 		}
 	} else {
 		// Search in raw JSON lines
-		totalLines := len(m.rawLines)
-		for i := 1; i <= totalLines; i++ {
-			var lineIdx int
-			if direction > 0 {
-				lineIdx = (m.cursorLine + i) % totalLines
-			} else {
-				lineIdx = (m.cursorLine - i + totalLines) % totalLines
-			}
-
-			if strings.Contains(strings.ToLower(m.rawLines[lineIdx]), queryLower) {
-				m.cursorLine = lineIdx
-				m.ensureCursorVisible()
-				return
-			}
+		if line, ok := nextHitLine(m.searchHits, m.cursorLine, direction); ok {
+			m.cursorLine = line
+			m.ensureCursorVisible()
 		}
 	}
 }
@@ -494,6 +1241,8 @@ func (m Model) View() string {
 			return m.viewMessageMode()
 		}
 		return m.viewJSONMode()
+	case StateSessionSearch:
+		return m.viewSessionSearch()
 	default:
 		return ""
 	}
@@ -529,7 +1278,7 @@ func (m Model) viewFileList() string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("j/k: navigate • enter: open • q: quit"))
+	b.WriteString(helpStyle.Render("j/k: navigate • enter: open • S: search all sessions • q: quit"))
 
 	return b.String()
 }
@@ -538,15 +1287,14 @@ func (m Model) viewJSONMode() string {
 	var b strings.Builder
 
 	// Header
-	fileName := ""
-	if m.fileIndex < len(m.files) {
-		fileName = m.files[m.fileIndex].Name
-	}
-	header := titleStyle.Render(fileName)
-	if m.searchQuery != "" {
-		header += "  " + searchStyle.Render(fmt.Sprintf("[/%s]", m.searchQuery))
+	header := titleStyle.Render(filepath.Base(m.sessionPath))
+	if m.redact {
+		header += "  " + statusStyle.Render("[redacted]")
 	}
-	lineInfo := helpStyle.Render(fmt.Sprintf("Line %d/%d", m.cursorLine+1, len(m.rawLines)))
+	header += m.searchIndicator()
+	header += m.pathIndicator()
+	header += m.costIndicator()
+	lineInfo := helpStyle.Render(fmt.Sprintf("Line %d/%d", m.originLine(m.cursorLine)+1, len(m.fullRawLines)))
 	headerPadding := m.width - lipgloss.Width(header) - lipgloss.Width(lineInfo)
 	if headerPadding < 1 {
 		headerPadding = 1
@@ -556,16 +1304,33 @@ func (m Model) viewJSONMode() string {
 	b.WriteString(strings.Repeat("─", m.width))
 	b.WriteString("\n")
 
-	// Two-column layout
+	// Two-column layout, or the preview alone when full-screen
 	leftWidth := m.leftPaneWidth()
 	rightWidth := m.rightPaneWidth()
 	viewHeight := m.viewerHeight()
 
+	if m.previewFull {
+		lines := m.buildRightPane(m.width, viewHeight)
+		for i := 0; i < viewHeight; i++ {
+			if i < len(lines) {
+				b.WriteString(lines[i])
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(m.jsonFooter())
+		return b.String()
+	}
+
 	// Build left pane (JSON with cursor)
 	leftLines := m.buildLeftPane(leftWidth, viewHeight)
 
-	// Build right pane (preview)
-	rightLines := m.buildRightPane(rightWidth, viewHeight)
+	// Build right pane (path query results, or the preview)
+	var rightLines []string
+	if m.pathQuery != nil {
+		rightLines = m.buildPathPanel(rightWidth, viewHeight)
+	} else {
+		rightLines = m.buildRightPane(rightWidth, viewHeight)
+	}
 
 	// Combine columns
 	for i := 0; i < viewHeight; i++ {
@@ -587,22 +1352,34 @@ func (m Model) viewJSONMode() string {
 		b.WriteString("\n")
 	}
 
-	// Footer
-	if m.searchMode {
-		b.WriteString(searchStyle.Render(fmt.Sprintf("/%s", m.searchInput)))
-	} else {
-		progress := ""
-		if len(m.rawLines) > 0 {
-			pct := (m.cursorLine + 1) * 100 / len(m.rawLines)
-			progress = fmt.Sprintf("%d%%", pct)
-		}
-		help := helpStyle.Render("j/k: move • Tab: message mode • /: search • q: back")
-		b.WriteString(fmt.Sprintf("%s  %s", progress, help))
-	}
-
+	b.WriteString(m.jsonFooter())
 	return b.String()
 }
 
+// jsonFooter renders the JSON mode footer: the search prompt, a status
+// message, or help for the focused pane
+func (m Model) jsonFooter() string {
+	if footer, ok := m.searchFooter(); ok {
+		return footer
+	}
+	progress := ""
+	if len(m.rawLines) > 0 {
+		pct := (m.cursorLine + 1) * 100 / len(m.rawLines)
+		progress = fmt.Sprintf("%d%%", pct)
+	}
+	help := helpStyle.Render("j/k: move • Tab: message mode • za: fold • zM/zR: fold/unfold all • z1-9: fold depth • p/P: preview/full • </>: resize • y: yank • /: search • |: path query • E/M: export html/md • R: redact • q: back")
+	switch {
+	case m.pathQuery != nil:
+		help = helpStyle.Render("}/{: next/prev value • F: only matching records • |: edit path • esc: close")
+	case m.previewFocus:
+		help = helpStyle.Render("PREVIEW j/k: scroll • ctrl+d/u: half page • gg/G: top/bottom • /: search • n/N: next/prev • P: full screen • </>: resize • esc/p: back")
+	}
+	if m.statusMessage != "" {
+		help = statusStyle.Render(m.statusMessage)
+	}
+	return fmt.Sprintf("%s  %s", progress, help)
+}
+
 // buildLeftPane builds the JSON viewer with cursor highlighting
 func (m Model) buildLeftPane(width, height int) []string {
 	var lines []string
@@ -615,19 +1392,17 @@ func (m Model) buildLeftPane(width, height int) []string {
 			continue
 		}
 
-		// Line number
+		// Line number (of the full content when records are hidden)
 		var lineNum string
 		if lineIdx == m.cursorLine {
-			lineNum = lineNumberSelectedStyle.Render(fmt.Sprintf("%d", lineIdx+1))
+			lineNum = lineNumberSelectedStyle.Render(fmt.Sprintf("%d", m.originLine(lineIdx)+1))
 		} else {
-			lineNum = lineNumberStyle.Render(fmt.Sprintf("%d", lineIdx+1))
+			lineNum = lineNumberStyle.Render(fmt.Sprintf("%d", m.originLine(lineIdx)+1))
 		}
 
 		// Content (use highlighted version, apply search highlighting if needed)
 		content := m.highlightedLines[lineIdx]
-		if m.searchQuery != "" {
-			content = HighlightSearch(content, m.searchQuery)
-		}
+		content = HighlightPattern(content, m.searchPattern)
 
 		// Truncate content to fit
 		maxContentWidth := width - lineNumWidth - 2
@@ -646,45 +1421,6 @@ func (m Model) buildLeftPane(width, height int) []string {
 	return lines
 }
 
-// buildRightPane builds the preview pane
-func (m Model) buildRightPane(width, height int) []string {
-	if m.cursorLine >= len(m.rawLines) {
-		return []string{noPreviewStyle.Render("No content")}
-	}
-
-	// Get the current line's raw content
-	rawLine := m.rawLines[m.cursorLine]
-
-	// Try to extract and render a string value
-	preview := RenderPreview(rawLine, width-2) // -2 for padding
-
-	if !preview.IsString {
-		msg := noPreviewStyle.Render("(no string value)")
-		return centerLines([]string{msg}, height)
-	}
-
-	// Build preview content
-	var content strings.Builder
-
-	// Header
-	if preview.Key != "" {
-		content.WriteString(titleStyle.Render(preview.Key))
-	} else {
-		content.WriteString(titleStyle.Render("String Value"))
-	}
-	content.WriteString("\n")
-	content.WriteString(strings.Repeat("─", width-2))
-	content.WriteString("\n")
-
-	// Rendered content (already word-wrapped by glamour)
-	content.WriteString(preview.Rendered)
-
-	// Split into lines - glamour already handles word wrapping
-	lines := strings.Split(content.String(), "\n")
-
-	return lines
-}
-
 var noPreviewStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("241")).
 	Italic(true)
@@ -755,20 +1491,79 @@ func centerLines(lines []string, height int) []string {
 	return result
 }
 
-// renderThread pre-renders all messages into a continuous thread
-func (m *Model) renderThread(width int) []string {
+// threadLayout maps messages and content blocks to rendered thread lines
+type threadLayout struct {
+	msgStart   []int      // First line of each message, -1 if filtered out
+	msgEnd     []int      // Line after the last line of each message
+	blockStart [][]int    // First line of each content block, -1 if not rendered
+	lineBlock  []blockRef // Owning block of each line
+}
+
+// blockRef identifies a content block. Block is -1 for a message's badge line
+// and Msg is -1 for separators between messages.
+type blockRef struct {
+	Msg, Block int
+}
+
+// renderThread pre-renders the visible messages into a continuous thread and
+// records where each message and block landed
+func (m *Model) renderThread(width int) {
 	var lines []string
+	layout := threadLayout{
+		msgStart:   make([]int, len(m.messages)),
+		msgEnd:     make([]int, len(m.messages)),
+		blockStart: make([][]int, len(m.messages)),
+	}
+
 	for i, msg := range m.messages {
-		rendered := msg.Render(width)
-		msgLines := strings.Split(rendered, "\n")
-		lines = append(lines, msgLines...)
+		if !m.messageVisible(i) {
+			layout.msgStart[i] = -1
+			layout.msgEnd[i] = -1
+			layout.blockStart[i] = make([]int, len(msg.Content))
+			for j := range layout.blockStart[i] {
+				layout.blockStart[i][j] = -1
+			}
+			continue
+		}
 
 		// Add separator between messages (blank line)
-		if i < len(m.messages)-1 {
+		if len(lines) > 0 {
 			lines = append(lines, "")
+			layout.lineBlock = append(layout.lineBlock, blockRef{Msg: -1, Block: -1})
+		}
+
+		rendered, offsets := msg.RenderLayout(width, m.blockDisplays(i))
+		msgLines := strings.Split(rendered, "\n")
+		if mark, ok := m.sessionBookmarks()[msg.UUID]; ok && msg.UUID != "" {
+			msgLines[0] += "  " + bookmarkLine(mark, width-lipgloss.Width(msgLines[0])-2)
+		}
+
+		start := len(lines)
+		layout.msgStart[i] = start
+		layout.blockStart[i] = make([]int, len(offsets))
+		for j, offset := range offsets {
+			layout.blockStart[i][j] = -1
+			if offset >= 0 {
+				layout.blockStart[i][j] = start + offset
+			}
+		}
+
+		// Attribute each line to the last block starting at or before it
+		block := -1
+		for k := range msgLines {
+			for j, offset := range offsets {
+				if offset >= 0 && offset == k {
+					block = j
+				}
+			}
+			layout.lineBlock = append(layout.lineBlock, blockRef{Msg: i, Block: block})
 		}
+		lines = append(lines, msgLines...)
+		layout.msgEnd[i] = len(lines)
 	}
-	return lines
+
+	m.renderedThread = lines
+	m.layout = layout
 }
 
 // viewMessageMode renders the message-focused view
@@ -776,27 +1571,30 @@ func (m Model) viewMessageMode() string {
 	var b strings.Builder
 
 	// Header
-	fileName := ""
-	if m.fileIndex < len(m.files) {
-		fileName = m.files[m.fileIndex].Name
+	header := titleStyle.Render(filepath.Base(m.sessionPath))
+	if m.redact {
+		header += "  " + statusStyle.Render("[redacted]")
 	}
-	header := titleStyle.Render(fileName)
-	if m.searchQuery != "" {
-		header += "  " + searchStyle.Render(fmt.Sprintf("[/%s]", m.searchQuery))
+	if m.filter != nil {
+		header += "  " + statusStyle.Render("[&"+m.filterQuery+"]")
 	}
+	header += m.kindIndicator()
+	header += m.searchIndicator()
+	header += m.costIndicator()
 
 	// Scroll position info
 	msgInfo := ""
 	if len(m.renderedThread) > 0 {
 		pct := (m.threadScrollOffset + 1) * 100 / len(m.renderedThread)
-		msgInfo = helpStyle.Render(fmt.Sprintf("%d%% (%d msgs)", pct, len(m.messages)))
+		count := fmt.Sprintf("msg %d/%d", m.visiblePosition(m.msgCursor), m.visibleMessageCount())
+		if visible := m.visibleMessageCount(); visible < len(m.messages) {
+			count += fmt.Sprintf(" of %d", len(m.messages))
+		}
+		msgInfo = helpStyle.Render(fmt.Sprintf("%d%% (%s)", pct, count))
 	}
 
 	// Mode indicator
-	modeIndicator := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("39")).
-		Bold(true).
-		Render("[MSG]")
+	modeIndicator := modeStyle.Render("[MSG]")
 
 	headerPadding := m.width - lipgloss.Width(header) - lipgloss.Width(msgInfo) - lipgloss.Width(modeIndicator) - 2
 	if headerPadding < 1 {
@@ -812,6 +1610,8 @@ func (m Model) viewMessageMode() string {
 
 	if len(m.messages) == 0 {
 		b.WriteString(helpStyle.Render("No messages to display"))
+	} else if len(m.renderedThread) == 0 {
+		b.WriteString(helpStyle.Render("No messages match the filter (& with an empty query and fx show all)"))
 	} else {
 		// Use pre-rendered thread
 		startLine := m.threadScrollOffset
@@ -819,29 +1619,64 @@ func (m Model) viewMessageMode() string {
 			startLine = 0
 		}
 
-		for i := 0; i < viewHeight && startLine+i < len(m.renderedThread); i++ {
-			line := m.renderedThread[startLine+i]
-			if m.searchQuery != "" {
-				line = HighlightSearch(line, m.searchQuery)
-			}
-			b.WriteString(line)
-			b.WriteString("\n")
+		var outlineLines, rawLines []string
+		outlineWidth := m.outlineWidth()
+		if m.showBookmarks {
+			outlineLines = m.buildBookmarkPane(outlineWidth, viewHeight)
+		} else if m.showOutline {
+			outlineLines = m.buildOutlinePane(outlineWidth, viewHeight)
+		}
+		threadWidth := m.threadWidth() + 1 // Including the spare column
+		if m.showSplit {
+			rawLines = m.buildRawPane(m.splitWidth(), viewHeight)
 		}
 
-		// Fill remaining height
-		visibleLines := len(m.renderedThread) - startLine
-		if visibleLines < viewHeight {
-			for i := visibleLines; i < viewHeight; i++ {
-				b.WriteString("\n")
+		for i := 0; i < viewHeight; i++ {
+			if m.sidebarShown() {
+				outlineLine := ""
+				if i < len(outlineLines) {
+					outlineLine = outlineLines[i]
+				}
+				b.WriteString(padOrTruncate(outlineLine, outlineWidth))
+				b.WriteString(" │ ")
 			}
+			line := ""
+			if startLine+i < len(m.renderedThread) {
+				line = m.renderedThread[startLine+i]
+				if m.lineInSearchScope(startLine + i) {
+					line = HighlightPattern(line, m.searchPattern)
+				}
+				gutter := " "
+				if m.layout.lineBlock[startLine+i].Msg == m.msgCursor {
+					gutter = messageCursorStyle.Render("▌")
+				}
+				b.WriteString(gutter)
+			} else if m.showSplit {
+				b.WriteString(" ")
+			}
+			if m.showSplit {
+				rawLine := ""
+				if i < len(rawLines) {
+					rawLine = rawLines[i]
+				}
+				b.WriteString(padOrTruncate(line, threadWidth))
+				b.WriteString(" │ ")
+				b.WriteString(padOrTruncate(rawLine, m.splitWidth()))
+			} else {
+				b.WriteString(line)
+			}
+			b.WriteString("\n")
 		}
 	}
 
 	// Footer
-	if m.searchMode {
-		b.WriteString(searchStyle.Render(fmt.Sprintf("/%s", m.searchInput)))
+	if footer, ok := m.searchFooter(); ok {
+		b.WriteString(footer)
 	} else {
-		help := helpStyle.Render("j/k: scroll • /: search • n/N: next/prev • Tab: JSON mode • q: back")
+		help := helpStyle.Render("j/k: scroll • J/K: message • {/}: same role • ctrl+e/y: scroll message • o: raw • s: split • t: outline • ]/[: turn • m/a: bookmark/note • y: yank • v/V/e: pager/all/editor • gf: open file • ': bookmarks • )/(: next/prev • za/zA: fold • zt/zu/zo/zm/zr: fold all • f: show/hide • /: search • &: filter • q: back")
+		if m.statusMessage != "" {
+			help = statusStyle.Render(m.statusMessage)
+		}
 		b.WriteString(help)
 	}
 
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveMessageCursor(t *testing.T) {
+	m := Model{width: 80, height: 40}
+	m.messages = []Message{
+		{Type: "user", Content: []ContentBlock{{Type: "text", Content: "first prompt"}}},
+		{Type: "assistant", Content: []ContentBlock{{Type: "tool_use", Name: "Bash", Content: `{"command": "ls"}`}}},
+		{Type: "user", Content: []ContentBlock{{Type: "tool_result", Content: "a.go"}}},
+		{Type: "assistant", Content: []ContentBlock{{Type: "text", Content: "One file"}}},
+		{Type: "user", Content: []ContentBlock{{Type: "text", Content: "second prompt"}}},
+		{Type: "assistant", Content: []ContentBlock{{Type: "text", Content: "Done"}}},
+	}
+	m.renderThread(m.threadWidth())
+
+	tests := []struct {
+		name      string
+		from      int
+		count     int
+		direction int
+		sameRole  bool
+		want      int
+	}{
+		{"J", 0, 1, 1, false, 1},
+		{"2J", 0, 2, 1, false, 2},
+		{"K", 3, 1, -1, false, 2},
+		{"} skips tool results", 0, 1, 1, true, 4},
+		{"} between assistant messages", 1, 1, 1, true, 3},
+		{"2{", 5, 2, -1, true, 1},
+		{"J at the end", 5, 1, 1, false, 5},
+		{"3K clamps at the start", 1, 3, -1, false, 0},
+		{"} past the last of a role", 4, 10, 1, true, 4},
+	}
+	for _, tt := range tests {
+		m.selectMessage(tt.from)
+		m.moveMessageCursor(tt.count, tt.direction, tt.sameRole)
+		if m.msgCursor != tt.want {
+			t.Errorf("%s from %d: selected %d, want %d", tt.name, tt.from, m.msgCursor, tt.want)
+		}
+	}
+}
+
+func TestScrollWithinMessage(t *testing.T) {
+	m := Model{width: 80, height: 12}
+	tall := strings.TrimSpace(strings.Repeat("line\n\n", 30))
+	m.messages = []Message{
+		{Type: "user", Content: []ContentBlock{{Type: "text", Content: "show me"}}},
+		{Type: "assistant", Content: []ContentBlock{{Type: "text", Content: tall}}},
+		{Type: "user", Content: []ContentBlock{{Type: "text", Content: "thanks"}}},
+	}
+	m.renderThread(m.threadWidth())
+	start, end := m.layout.msgStart[1], m.layout.msgEnd[1]
+	if end-start <= m.viewerHeight() {
+		t.Fatalf("Message 1 should be taller than the view (%d lines)", end-start)
+	}
+
+	m.selectMessage(1)
+	tests := []struct {
+		name      string
+		count     int
+		direction int
+		want      int
+	}{
+		{"ctrl+e", 1, 1, start + 1},
+		{"5 ctrl+e", 5, 1, start + 6},
+		{"ctrl+y", 2, -1, start + 4},
+		{"ctrl+e stops at the last line", 1000, 1, end - m.viewerHeight()},
+		{"ctrl+y stops at the first line", 1000, -1, start},
+	}
+	for _, tt := range tests {
+		m.scrollWithinMessage(tt.count, tt.direction)
+		if m.threadScrollOffset != tt.want || m.msgCursor != 1 {
+			t.Errorf("%s: offset %d on message %d, want %d on message 1", tt.name, m.threadScrollOffset, m.msgCursor, tt.want)
+		}
+	}
+
+	// Scrolling the selected message out of view moves the cursor along
+	m.selectMessage(0)
+	m.handleMessageNavigation(start+3-m.threadScrollOffset, 1)
+	if m.msgCursor != 1 {
+		t.Errorf("Cursor should follow the scroll to message 1, got %d", m.msgCursor)
+	}
+	m.handleMessageNavigation(len(m.renderedThread), -1)
+	if m.threadScrollOffset != 0 || m.msgCursor != 1 {
+		t.Errorf("Scrolling to the top keeps message 1 selected while it is in view: offset %d, message %d", m.threadScrollOffset, m.msgCursor)
+	}
+}
+
+func TestMessageRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	data := `{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}
+{"type":"file-history-snapshot","messageId":"u1","snapshot":{}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"hello"}]}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := Model{width: 100, height: 30, state: StateViewer, ready: true}
+	if err := m.openSession(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.msgRecord) != 2 || m.msgRecord[0] != 0 || m.msgRecord[1] != 2 {
+		t.Fatalf("Messages should map to records 0 and 2, got %v", m.msgRecord)
+	}
+
+	m.selectMessage(1)
+	m.openRawMessage()
+	if m.viewMode != ViewModeJSON {
+		t.Fatal("o should switch to JSON mode")
+	}
+	if line := m.originLine(m.cursorLine); line != m.records[2].Start {
+		t.Errorf("o should put the cursor on line %d, got %d", m.records[2].Start, line)
+	}
+
+	m.viewMode = ViewModeMessage
+	m.msgCursor = 2
+	m.openRawMessage()
+	if m.viewMode != ViewModeMessage || m.statusMessage != "No raw record for this message" {
+		t.Errorf("o without a record should stay put: %q", m.statusMessage)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// nodeAtLine returns the innermost node on a line of the full content, and
+// its path from the record root. Closing braces belong to their container.
+func (n *jsonNode) nodeAtLine(line int) (*jsonNode, string) {
+	path := "$"
+	for n != nil && line >= n.Start && line < n.End {
+		i := sort.Search(len(n.Children), func(i int) bool { return n.Children[i].End > line })
+		if i >= len(n.Children) || n.Children[i].Start > line {
+			return n, path
+		}
+		child := n.Children[i]
+		if child.HasKey {
+			path += pathKeySegment(child.Key)
+		} else {
+			path += fmt.Sprintf("[%d]", i)
+		}
+		n = child
+	}
+	return nil, ""
+}
+
+// nodePreview renders the node under the JSON cursor for the preview pane:
+// strings in full, numbers and timestamps with their readings, objects and
+// arrays as tables. ok is false when the line is not part of a JSON record.
+func (m Model) nodePreview(width int) (title string, body string, ok bool) {
+	line := m.originLine(m.cursorLine)
+	r := m.recordAtLine(line)
+	if r < 0 || m.jsonRoots[r] == nil {
+		return "", "", false
+	}
+	n, path := m.jsonRoots[r].nodeAtLine(line)
+	if n == nil {
+		return "", "", false
+	}
+	return path, renderNodePreview(n, width), true
+}
+
+// renderNodePreview renders a node's value with a line describing its type
+func renderNodePreview(n *jsonNode, width int) string {
+	width = max(width, 20)
+	switch v := n.Value.(type) {
+	case string:
+		info := fmt.Sprintf("string · %d chars", utf8.RuneCountInString(v))
+		if lines := strings.Count(v, "\n") + 1; lines > 1 {
+			info += fmt.Sprintf(" · %d lines", lines)
+		}
+		return helpStyle.Render(info) + "\n\n" + previewStringValue(v, width)
+
+	case float64:
+		lines := []string{toolValueStyle.Render(strconv.FormatFloat(v, 'f', -1, 64))}
+		if t, ok := numericTimestamp(n.Key, v); ok {
+			lines = append(append(lines, ""), timestampLines(t, time.Now())...)
+		}
+		return helpStyle.Render("number") + "\n\n" + strings.Join(lines, "\n")
+
+	case bool:
+		return helpStyle.Render("boolean") + "\n\n" + boolStyle.Render(strconv.FormatBool(v))
+
+	case nil:
+		return helpStyle.Render("null") + "\n\n" + boolStyle.Render("null")
+
+	case map[string]interface{}:
+		size, _ := json.Marshal(v)
+		info := fmt.Sprintf("object · %d %s · %s", len(v), plural(len(v), "key"), formatSize(len(size)))
+		return helpStyle.Render(info) + "\n\n" + objectTable(v, width)
+
+	case []interface{}:
+		size, _ := json.Marshal(v)
+		info := fmt.Sprintf("array · %d %s · %s", len(v), plural(len(v), "item"), formatSize(len(size)))
+		return helpStyle.Render(info) + "\n\n" + arrayTable(v, width)
+	}
+	return ""
+}
+
+// previewStringValue renders a string: timestamps with their local and
+// relative times, base64 decoded, anything else as text
+func previewStringValue(s string, width int) string {
+	if t, ok := parseTimestamp(s); ok {
+		return strings.Join(append([]string{toolValueStyle.Render(s), ""}, timestampLines(t, time.Now())...), "\n")
+	}
+	if data, ok := decodeBase64(s); ok {
+		return describeBinary(data, width)
+	}
+	return renderText(s, width)
+}
+
+// parseTimestamp parses an RFC 3339 timestamp
+func parseTimestamp(s string) (time.Time, bool) {
+	if len(s) < 20 || len(s) > 40 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// numericTimestamp reads a number as a Unix time: milliseconds when it is in
+// the range of recent dates in milliseconds, seconds only when the key says
+// it is a time
+func numericTimestamp(key string, v float64) (time.Time, bool) {
+	if v != math.Trunc(v) {
+		return time.Time{}, false
+	}
+	lower := strings.ToLower(key)
+	timeKey := strings.Contains(lower, "time") || strings.Contains(lower, "date") ||
+		strings.HasSuffix(lower, "_at") || strings.HasSuffix(key, "At")
+	switch {
+	case v >= 1e12 && v < 1e13:
+		return time.UnixMilli(int64(v)), true
+	case timeKey && v >= 1e9 && v < 1e10:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+// timestampLines shows a time in local time and relative to now
+func timestampLines(t, now time.Time) []string {
+	return []string{
+		toolLabelStyle.Render("local:    ") + t.Local().Format("2006-01-02 15:04:05 MST (Mon)"),
+		toolLabelStyle.Render("relative: ") + relativeTime(t, now),
+	}
+}
+
+// relativeTime describes t relative to now, e.g. "3 hours ago"
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := " ago"
+	if d < 0 {
+		d, suffix = -d, " from now"
+	}
+	var n int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int(d.Minutes()), "minute"
+	case d < 48*time.Hour:
+		n, unit = int(d.Hours()), "hour"
+	case d < 60*24*time.Hour:
+		n, unit = int(d.Hours()/24), "day"
+	case d < 730*24*time.Hour:
+		n, unit = int(d.Hours()/24/30), "month"
+	default:
+		n, unit = int(d.Hours()/24/365), "year"
+	}
+	return fmt.Sprintf("%d %s%s", n, plural(n, unit), suffix)
+}
+
+var base64Pattern = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+
+// decodeBase64 decodes strings that are plainly base64: long, padded to a
+// multiple of four and using only the base64 alphabet
+func decodeBase64(s string) ([]byte, bool) {
+	if len(s) < 32 || len(s)%4 != 0 || !base64Pattern.MatchString(s) {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	return data, err == nil
+}
+
+// binarySignatures identifies common file types by their first bytes
+var binarySignatures = []struct {
+	magic string
+	kind  string
+}{
+	{"\x89PNG\r\n\x1a\n", "PNG image"},
+	{"\xff\xd8\xff", "JPEG image"},
+	{"GIF87a", "GIF image"},
+	{"GIF89a", "GIF image"},
+	{"RIFF", "RIFF (WebP/WAV) data"},
+	{"%PDF-", "PDF document"},
+	{"PK\x03\x04", "ZIP archive"},
+	{"\x1f\x8b", "gzip data"},
+}
+
+// describeBinary renders decoded base64: text is shown rendered for its
+// content type, known file types are named, and anything else gets a hex
+// dump of its start
+func describeBinary(data []byte, width int) string {
+	header := toolLabelStyle.Render(fmt.Sprintf("base64 → %s", formatSize(len(data))))
+	for _, sig := range binarySignatures {
+		if strings.HasPrefix(string(data), sig.magic) {
+			return header + "\n" + toolValueStyle.Render(sig.kind)
+		}
+	}
+	if utf8.Valid(data) {
+		text := string(data)
+		kind, lang := classifyContent(text)
+		if lang == "" {
+			lang = kind.String()
+		}
+		return header + toolNoteStyle.Render(" ("+lang+")") + "\n\n" + renderText(text, width)
+	}
+
+	var dump []string
+	for off := 0; off < len(data) && off < 256; off += 16 {
+		row := data[off:min(off+16, len(data))]
+		dump = append(dump, fmt.Sprintf("%08x  % x", off, row))
+	}
+	return header + toolNoteStyle.Render(" (binary)") + "\n\n" + strings.Join(dump, "\n")
+}
+
+// summarizeValue renders a value on one line for a table cell
+func summarizeValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return firstLine(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return fmt.Sprintf("{%d %s}", len(v), plural(len(v), "key"))
+	case []interface{}:
+		return fmt.Sprintf("[%d %s]", len(v), plural(len(v), "item"))
+	}
+	return fmt.Sprint(v)
+}
+
+// objectTable lists an object's keys with their values summarised
+func objectTable(obj map[string]interface{}, width int) string {
+	keys := make([]string, 0, len(obj))
+	keyWidth := 0
+	for k := range obj {
+		keys = append(keys, k)
+		keyWidth = max(keyWidth, utf8.RuneCountInString(k))
+	}
+	sort.Strings(keys)
+	keyWidth = min(keyWidth, width/3)
+
+	var lines []string
+	for _, k := range keys {
+		key := padOrTruncate(truncateText(k, keyWidth), keyWidth)
+		value := truncateText(summarizeValue(obj[k]), max(1, width-keyWidth-2))
+		lines = append(lines, keyStyle.Render(key)+"  "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// arrayTable renders an array of objects as a table with a column per key
+// (most common keys first, as many as fit), and other arrays as an indexed
+// list
+func arrayTable(arr []interface{}, width int) string {
+	if len(arr) == 0 {
+		return noPreviewStyle.Render("(empty)")
+	}
+	counts := make(map[string]int)
+	for _, elem := range arr {
+		obj, ok := elem.(map[string]interface{})
+		if !ok {
+			return indexedList(arr, width)
+		}
+		for k := range obj {
+			counts[k]++
+		}
+	}
+
+	columns := make([]string, 0, len(counts))
+	for k := range counts {
+		columns = append(columns, k)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if counts[columns[i]] != counts[columns[j]] {
+			return counts[columns[i]] > counts[columns[j]]
+		}
+		return columns[i] < columns[j]
+	})
+
+	// Size each column to its widest cell, up to a cap, while they fit
+	const maxColumnWidth = 30
+	indexWidth := len(strconv.Itoa(len(arr) - 1))
+	used := indexWidth
+	var widths []int
+	for _, col := range columns {
+		w := utf8.RuneCountInString(col)
+		for _, elem := range arr {
+			w = max(w, utf8.RuneCountInString(summarizeValue(elem.(map[string]interface{})[col])))
+		}
+		w = min(w, maxColumnWidth)
+		if used+2+w > width && len(widths) > 0 {
+			break
+		}
+		widths = append(widths, w)
+		used += 2 + w
+	}
+	columns = columns[:len(widths)]
+
+	row := func(index string, cells []string) string {
+		parts := []string{padOrTruncate(index, indexWidth)}
+		for i, cell := range cells {
+			parts = append(parts, padOrTruncate(truncateText(cell, widths[i]), widths[i]))
+		}
+		return strings.Join(parts, "  ")
+	}
+
+	lines := []string{keyStyle.Render(row("#", columns))}
+	for i, elem := range arr {
+		obj := elem.(map[string]interface{})
+		cells := make([]string, len(columns))
+		for c, col := range columns {
+			if v, ok := obj[col]; ok {
+				cells[c] = summarizeValue(v)
+			}
+		}
+		lines = append(lines, row(strconv.Itoa(i), cells))
+	}
+	if hidden := len(counts) - len(columns); hidden > 0 {
+		lines = append(lines, "", toolNoteStyle.Render(fmt.Sprintf("%d more %s not shown", hidden, plural(hidden, "column"))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indexedList renders the elements of an array one per line
+func indexedList(arr []interface{}, width int) string {
+	indexWidth := len(strconv.Itoa(len(arr) - 1))
+	var lines []string
+	for i, elem := range arr {
+		index := fmt.Sprintf("%*d", indexWidth, i)
+		lines = append(lines, lineNumberStyle.UnsetWidth().Render(index)+"  "+
+			truncateText(summarizeValue(elem), max(1, width-indexWidth-2)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNodeAtLine(t *testing.T) {
+	m := openJSONTreeSession(t)
+	root := m.jsonRoots[0]
+	tests := []struct {
+		line string
+		path string
+	}{
+		{`{`, "$"},
+		{`                "text": "a \u003cb\u003e \u0026 c",`, "$.message.content[0].text"},
+		{`            "input_tokens": 12,`, "$.message.usage.input_tokens"},
+		{`        }`, "$.message.usage"},
+	}
+	for _, tt := range tests {
+		line := -1
+		for i := root.Start; i < root.End; i++ {
+			if m.fullRawLines[i] == tt.line {
+				line = i
+			}
+		}
+		if line < 0 {
+			t.Fatalf("No line %q in:\n%s", tt.line, strings.Join(m.fullRawLines, "\n"))
+		}
+		if _, path := root.nodeAtLine(line); path != tt.path {
+			t.Errorf("nodeAtLine(%q) path = %q, want %q", tt.line, path, tt.path)
+		}
+	}
+}
+
+func TestRenderNodePreview(t *testing.T) {
+	preview := func(key string, value interface{}) string {
+		return StripAnsi(renderNodePreview(&jsonNode{Key: key, HasKey: key != "", Value: value}, 60))
+	}
+
+	if got := preview("timestamp", "2026-09-02T10:00:00Z"); !strings.Contains(got, "local:") || !strings.Contains(got, "relative:") {
+		t.Errorf("Timestamp preview lacks readings:\n%s", got)
+	}
+	if got := preview("createdAt", float64(1788300000)); !strings.Contains(got, "local:") {
+		t.Errorf("Unix seconds under a time key should read as a time:\n%s", got)
+	}
+	if got := preview("input_tokens", float64(1788300000)); strings.Contains(got, "local:") {
+		t.Errorf("Plain numbers should not read as times:\n%s", got)
+	}
+
+	png := base64.StdEncoding.EncodeToString(append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 40)...))
+	if got := preview("data", png); !strings.Contains(got, "PNG image") {
+		t.Errorf("base64 PNG not recognised:\n%s", got)
+	}
+	text := base64.StdEncoding.EncodeToString([]byte(`{"decoded": "json payload", "n": 1}`))
+	if got := preview("data", text); !strings.Contains(got, "(json)") || !strings.Contains(got, "json payload") {
+		t.Errorf("base64 text not decoded:\n%s", got)
+	}
+
+	table := preview("content", []interface{}{
+		map[string]interface{}{"type": "text", "text": "hello"},
+		map[string]interface{}{"type": "tool_use", "name": "Bash"},
+	})
+	for _, want := range []string{"array · 2 items", "#  type", "0  text            hello", "1  tool_use"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Array table lacks %q:\n%s", want, table)
+		}
+	}
+	if got := preview("usage", map[string]interface{}{"input_tokens": float64(3), "cache": map[string]interface{}{}}); !strings.Contains(got, "cache         {0 keys}") {
+		t.Errorf("Object table:\n%s", got)
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 9, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[time.Duration]string{
+		30 * time.Second:     "just now",
+		5 * time.Minute:      "5 minutes ago",
+		3 * time.Hour:        "3 hours ago",
+		8 * 24 * time.Hour:   "8 days ago",
+		-1 * time.Hour:       "1 hour from now",
+		400 * 24 * time.Hour: "13 months ago",
+	}
+	for d, want := range tests {
+		if got := relativeTime(now.Add(-d), now); got != want {
+			t.Errorf("relativeTime(-%v) = %q, want %q", d, got, want)
+		}
+	}
+}
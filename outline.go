@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// outlineTurn is one turn of a session: a user prompt and everything up to
+// the next one
+type outlineTurn struct {
+	Msg       int // Index of the prompt message
+	Prompt    string
+	Time      time.Time
+	ToolCalls int
+	Duration  time.Duration // From the prompt to the turn's last timestamp
+}
+
+// buildOutline splits messages into turns at each user prompt. Messages
+// before the first prompt belong to no turn.
+func buildOutline(messages []Message) []outlineTurn {
+	var turns []outlineTurn
+	for i := range messages {
+		msg := &messages[i]
+		if messageKind(msg) == KindUserPrompt {
+			turn := outlineTurn{Msg: i, Time: msg.Timestamp}
+			for _, block := range msg.Content {
+				if block.Type == "text" || block.Type == blockCommand {
+					turn.Prompt = firstLine(block.Content)
+					break
+				}
+			}
+			turns = append(turns, turn)
+		}
+		if len(turns) == 0 {
+			continue
+		}
+
+		turn := &turns[len(turns)-1]
+		for _, block := range msg.Content {
+			if block.Type == "tool_use" {
+				turn.ToolCalls++
+			}
+		}
+		if !turn.Time.IsZero() && msg.Timestamp.After(turn.Time) {
+			turn.Duration = msg.Timestamp.Sub(turn.Time)
+		}
+	}
+	return turns
+}
+
+// formatTurnDuration renders a turn duration compactly, e.g. "42s", "3m20s"
+// or "1h05m"
+func formatTurnDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// outlineWidth returns the width of the outline sidebar
+func (m Model) outlineWidth() int {
+	return max(20, min(40, m.width/4))
+}
+
+// threadWidth returns the width messages are rendered at, leaving room for
+// the cursor gutter and, when shown, the outline and raw JSON panes
+func (m Model) threadWidth() int {
+	width := m.width - 2
+	if m.sidebarShown() {
+		width -= m.outlineWidth() + 3 // Pane and separator
+	}
+	if m.showSplit {
+		width -= m.splitWidth() + 3
+	}
+	return width
+}
+
+// sidebarShown reports whether the outline or bookmark sidebar is shown
+func (m Model) sidebarShown() bool {
+	return m.showOutline || m.showBookmarks
+}
+
+// currentTurn returns the turn holding the selected message, or -1 before
+// the first prompt
+func (m Model) currentTurn() int {
+	current := -1
+	for k, turn := range m.outline {
+		if turn.Msg > m.msgCursor {
+			break
+		}
+		current = k
+	}
+	return current
+}
+
+// toggleOutline shows or hides the outline, keeping the selected message in
+// place as the thread re-wraps
+func (m *Model) toggleOutline() {
+	m.showOutline = !m.showOutline || m.showBookmarks
+	m.showBookmarks = false
+	m.rerenderAnchored(blockRef{Msg: m.msgCursor, Block: -1})
+}
+
+// moveTurn scrolls the start of the count-th turn after (direction > 0) or
+// before the current one to the top. A turn whose prompt is hidden starts at
+// its first shown message.
+func (m *Model) moveTurn(count, direction int) {
+	if len(m.outline) == 0 {
+		return
+	}
+	current := m.currentTurn()
+	if direction < 0 && current >= 0 && m.layout.msgStart[m.outline[current].Msg] < m.threadScrollOffset {
+		count-- // "[" first goes back to the start of the current turn
+	}
+	target := max(0, min(len(m.outline)-1, current+count*direction))
+
+	line := m.nextShownLine(m.outline[target].Msg)
+	m.threadScrollOffset = line
+	if i := m.messageAtLine(line); i >= 0 {
+		m.msgCursor = i
+	}
+	m.handleMessageNavigation(0, 1) // Clamp
+}
+
+// buildOutlinePane renders the outline: two rows per turn, the current turn
+// highlighted and kept in view
+func (m Model) buildOutlinePane(width, height int) []string {
+	count := fmt.Sprintf("%d turns", len(m.outline))
+	if len(m.outline) == 1 {
+		count = "1 turn"
+	}
+	lines := []string{
+		titleStyle.Render("Outline"),
+		helpStyle.Render(count),
+		strings.Repeat("─", width),
+	}
+	if len(m.outline) == 0 {
+		return append(lines, noPreviewStyle.Render("No prompts"))
+	}
+
+	current := m.currentTurn()
+	rows := max(1, (height-len(lines))/2)
+	start := max(0, min(current-rows/2, len(m.outline)-rows))
+
+	for k := start; k < len(m.outline) && k < start+rows; k++ {
+		turn := m.outline[k]
+		ts := "     "
+		if !turn.Time.IsZero() {
+			ts = turn.Time.Local().Format("15:04")
+		}
+		title := padOrTruncate(truncateText(ts+"  "+turn.Prompt, width), width)
+
+		var stats []string
+		if turn.ToolCalls > 0 {
+			stats = append(stats, fmt.Sprintf("🔧 %d", turn.ToolCalls))
+		}
+		if turn.Duration > 0 {
+			stats = append(stats, formatTurnDuration(turn.Duration))
+		}
+		detail := truncateText("       "+strings.Join(stats, " · "), width)
+
+		switch {
+		case k == current:
+			lines = append(lines, selectedStyle.Render(title))
+		case m.layout.msgStart[turn.Msg] < 0:
+			lines = append(lines, helpStyle.Render(title)) // Prompt hidden
+		default:
+			lines = append(lines, normalStyle.Render(title))
+		}
+		lines = append(lines, helpStyle.Render(detail))
+	}
+	return lines
+}
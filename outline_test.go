@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildOutline(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2026, 3, 1, 10, 0, sec, 0, time.UTC) }
+	messages := []Message{
+		{Type: "system", Content: []ContentBlock{{Type: "plain", Content: "Session start"}}},
+		{Type: "user", Timestamp: at(0), Content: []ContentBlock{{Type: "text", Content: "\nRun the tests\nplease"}}},
+		{Type: "assistant", Timestamp: at(5), Content: []ContentBlock{
+			{Type: "tool_use", Name: "Bash", Content: `{"command": "go test"}`},
+			{Type: "tool_use", Name: "Read", Content: `{"file_path": "x.go"}`},
+		}},
+		{Type: "user", Timestamp: at(20), Content: []ContentBlock{{Type: "tool_result", Content: "ok"}}},
+		{Type: "assistant", Timestamp: at(95), Content: []ContentBlock{{Type: "text", Content: "All pass"}}},
+		{Type: "user", Timestamp: at(100), Content: []ContentBlock{{Type: "text", Content: "Thanks"}}},
+	}
+
+	turns := buildOutline(messages)
+	if len(turns) != 2 {
+		t.Fatalf("got %d turns, want 2", len(turns))
+	}
+	first := turns[0]
+	if first.Msg != 1 || first.Prompt != "Run the tests" || first.ToolCalls != 2 || first.Duration != 95*time.Second {
+		t.Errorf("first turn = %+v", first)
+	}
+	if turns[1].Msg != 5 || turns[1].ToolCalls != 0 || turns[1].Duration != 0 {
+		t.Errorf("second turn = %+v", turns[1])
+	}
+	if got := formatTurnDuration(first.Duration); got != "1m35s" {
+		t.Errorf("formatTurnDuration = %q, want 1m35s", got)
+	}
+}
+
+func TestMoveTurn(t *testing.T) {
+	m := Model{width: 80, height: 8}
+	for i := 0; i < 3; i++ {
+		m.messages = append(m.messages,
+			Message{Type: "user", Content: []ContentBlock{{Type: "text", Content: "prompt"}}},
+			Message{Type: "assistant", Content: []ContentBlock{{Type: "text", Content: "one\n\ntwo\n\nthree"}}},
+		)
+	}
+	m.outline = buildOutline(m.messages)
+	m.showOutline = true
+	m.renderThread(m.threadWidth())
+
+	m.moveTurn(1, 1)
+	if m.msgCursor != 2 || m.currentTurn() != 1 {
+		t.Errorf("] selected message %d (turn %d), want 2 (turn 1)", m.msgCursor, m.currentTurn())
+	}
+	m.handleMessageNavigation(1, 1)
+	m.moveTurn(1, -1)
+	if m.threadScrollOffset != m.layout.msgStart[2] {
+		t.Errorf("[ inside a turn should go to its start, offset %d", m.threadScrollOffset)
+	}
+	m.moveTurn(1, -1)
+	if m.msgCursor != 0 || m.currentTurn() != 0 {
+		t.Errorf("[ selected message %d (turn %d), want 0 (turn 0)", m.msgCursor, m.currentTurn())
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ColorsConfig overrides the palette. Colours are ANSI 256 numbers ("0" to
+// "255") or hex ("#rgb", "#rrggbb").
+type ColorsConfig struct {
+	Roles map[string]RoleColors `json:"roles"` // Message badges: user, assistant, system, summary, unknown
+	JSON  map[string]string     `json:"json"`  // JSON tokens: key, string, number, bool, brace
+	UI    map[string]string     `json:"ui"`    // Everything else, see uiColors
+}
+
+// RoleColors are the colours of a message badge
+type RoleColors struct {
+	Foreground string `json:"fg"`
+	Background string `json:"bg"`
+}
+
+// roleStyles are the badge styles roles can recolour
+var roleStyles = map[string]*lipgloss.Style{
+	"user":      &userBadgeStyle,
+	"assistant": &assistantBadgeStyle,
+	"system":    &systemBadgeStyle,
+	"summary":   &summaryBadgeStyle,
+	"unknown":   &unknownBadgeStyle,
+}
+
+// jsonTokenStyles are the JSON token styles that can be recoloured
+var jsonTokenStyles = map[string]*lipgloss.Style{
+	"key":    &keyStyle,
+	"string": &strStyle,
+	"number": &numStyle,
+	"bool":   &boolStyle, // Also null
+	"brace":  &braceStyle,
+}
+
+// uiColors set the colour of the other named parts of the interface. An
+// empty colour leaves the terminal's own.
+var uiColors = map[string]func(string){
+	"title": func(c string) {
+		titleStyle = titleStyle.Foreground(color(c))
+		previewHeaderStyle = previewHeaderStyle.Foreground(color(c))
+	},
+	"selected":      func(c string) { selectedStyle = selectedStyle.Background(color(c)) },
+	"selected_text": func(c string) { selectedStyle = selectedStyle.Foreground(color(c)) },
+	"text": func(c string) {
+		normalStyle = normalStyle.Foreground(color(c))
+		toolValueStyle = toolValueStyle.Foreground(color(c))
+		diffFileStyle = diffFileStyle.Foreground(color(c))
+	},
+	"help": func(c string) {
+		helpStyle = helpStyle.Foreground(color(c))
+		noPreviewStyle = noPreviewStyle.Foreground(color(c))
+	},
+	"search":               func(c string) { searchStyle = searchStyle.Foreground(color(c)) },
+	"status":               func(c string) { statusStyle = statusStyle.Foreground(color(c)) },
+	"cursor_line":          func(c string) { cursorLineStyle = cursorLineStyle.Background(color(c)) },
+	"line_number":          func(c string) { lineNumberStyle = lineNumberStyle.Foreground(color(c)) },
+	"line_number_selected": func(c string) { lineNumberSelectedStyle = lineNumberSelectedStyle.Foreground(color(c)) },
+	"message_cursor":       func(c string) { messageCursorStyle = messageCursorStyle.Foreground(color(c)) },
+	"mode":                 func(c string) { modeStyle = modeStyle.Foreground(color(c)) },
+	"warning":              func(c string) { warningStyle = warningStyle.Foreground(color(c)) },
+	"thinking":             func(c string) { thinkingStyle = thinkingStyle.Foreground(color(c)) },
+	"tool_use":             func(c string) { toolUseHeaderStyle = toolUseHeaderStyle.Foreground(color(c)) },
+	"tool_result":          func(c string) { toolResultHeaderStyle = toolResultHeaderStyle.Foreground(color(c)) },
+	"tool_label":           func(c string) { toolLabelStyle = toolLabelStyle.Foreground(color(c)) },
+	"tool_note":            func(c string) { toolNoteStyle = toolNoteStyle.Foreground(color(c)) },
+	"border": func(c string) {
+		messageBorderStyle = messageBorderStyle.BorderForeground(color(c))
+		commandPanelStyle = commandPanelStyle.BorderForeground(color(c))
+	},
+	"meta":    func(c string) { metaMessageStyle = metaMessageStyle.Foreground(color(c)) },
+	"command": func(c string) { commandStyle = commandStyle.Foreground(color(c)) },
+	"reminder": func(c string) {
+		reminderStyle = reminderStyle.Foreground(color(c))
+		reminderBodyStyle = reminderBodyStyle.BorderForeground(color(c))
+	},
+	"bookmark":          func(c string) { bookmarkStyle = bookmarkStyle.Foreground(color(c)) },
+	"diff_hunk":         func(c string) { diffHunkStyle = diffHunkStyle.Foreground(color(c)) },
+	"diff_removed":      func(c string) { diffRemovedStyle = diffRemovedStyle.Foreground(color(c)) },
+	"diff_added":        func(c string) { diffAddedStyle = diffAddedStyle.Foreground(color(c)) },
+	"diff_removed_line": func(c string) { diffRemovedBackground = sgr(sgrColor(c, true)) },
+	"diff_added_line":   func(c string) { diffAddedBackground = sgr(sgrColor(c, true)) },
+	"search_match": func(c string) {
+		searchMatchBackground = c
+		searchHighlightStart = searchHighlightSGR()
+	},
+	"search_match_text": func(c string) {
+		searchMatchText = c
+		searchHighlightStart = searchHighlightSGR()
+	},
+}
+
+// color converts a palette colour to a lipgloss one; "" is no colour
+func color(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
+
+// sgrColor returns the SGR parameters that set a palette colour as the
+// foreground or background, or "" for no colour
+func sgrColor(c string, background bool) string {
+	base, basic, bright := "38", 30, 90
+	if background {
+		base, basic, bright = "48", 40, 100
+	}
+	if hexColorPattern.MatchString(c) {
+		hex := c[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		rgb, _ := strconv.ParseUint(hex, 16, 32)
+		return fmt.Sprintf("%s;2;%d;%d;%d", base, rgb>>16, rgb>>8&0xff, rgb&0xff)
+	}
+	n, err := strconv.Atoi(c)
+	switch {
+	case err != nil:
+		return ""
+	case n < 8: // The basic and bright colours have codes of their own
+		return strconv.Itoa(basic + n)
+	case n < 16:
+		return strconv.Itoa(bright + n - 8)
+	}
+	return base + ";5;" + c
+}
+
+// sgr wraps SGR parameters in an escape sequence, or returns "" for none
+func sgr(params string) string {
+	if params == "" {
+		return ""
+	}
+	return "\x1b[" + params + "m"
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validColor reports whether s is an ANSI 256 colour number or a hex colour
+func validColor(s string) bool {
+	if hexColorPattern.MatchString(s) {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255 && strconv.Itoa(n) == s
+}
+
+func (c ColorsConfig) validate() error {
+	for _, role := range sortedKeys(c.Roles) {
+		if roleStyles[role] == nil {
+			return fmt.Errorf("colors.roles: unknown role %q (expected one of %s)", role, quotedList(roleStyles))
+		}
+		colors := c.Roles[role]
+		if err := checkColor("colors.roles."+role+".fg", colors.Foreground); err != nil {
+			return err
+		}
+		if err := checkColor("colors.roles."+role+".bg", colors.Background); err != nil {
+			return err
+		}
+	}
+	for _, token := range sortedKeys(c.JSON) {
+		if jsonTokenStyles[token] == nil {
+			return fmt.Errorf("colors.json: unknown token type %q (expected one of %s)", token, quotedList(jsonTokenStyles))
+		}
+		if err := checkColor("colors.json."+token, c.JSON[token]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(c.UI) {
+		if uiColors[name] == nil {
+			return fmt.Errorf("colors.ui: unknown element %q (expected one of %s)", name, quotedList(uiColors))
+		}
+		if err := checkColor("colors.ui."+name, c.UI[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkColor validates an optional colour setting
+func checkColor(name, value string) error {
+	if value != "" && !validColor(value) {
+		return fmt.Errorf(`%s: %q is not a colour (use "0" to "255" or "#rrggbb")`, name, value)
+	}
+	return nil
+}
+
+// applyColors recolours the package styles with the colours set in c,
+// leaving the others as they are. It runs once at startup, after the theme
+// and before anything is rendered.
+func applyColors(c ColorsConfig) {
+	for role, colors := range c.Roles {
+		style := roleStyles[role]
+		if colors.Foreground != "" {
+			*style = style.Foreground(color(colors.Foreground))
+		}
+		if colors.Background != "" {
+			*style = style.Background(color(colors.Background))
+		}
+	}
+	for token, c := range c.JSON {
+		if c != "" {
+			style := jsonTokenStyles[token]
+			*style = style.Foreground(color(c))
+		}
+	}
+	for name, c := range c.UI {
+		if c != "" {
+			uiColors[name](c)
+		}
+	}
+}
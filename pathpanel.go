@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// applyPathQuery runs a path query against every record and jumps to the
+// first hit. An empty query closes the results panel.
+func (m *Model) applyPathQuery(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		m.clearPathQuery()
+		return
+	}
+
+	q, err := ParsePathQuery(query)
+	if err != nil {
+		m.statusMessage = "Invalid path: " + err.Error()
+		return
+	}
+	m.pathQueryText = query
+	m.pathQuery = q
+	m.refreshPathQuery()
+	if len(m.pathHits) == 0 {
+		m.statusMessage = "No values at " + query
+		return
+	}
+	m.cursorLine = -1
+	m.findNextPathHit(1)
+}
+
+// clearPathQuery closes the results panel and shows every record again
+func (m *Model) clearPathQuery() {
+	m.pathQueryText = ""
+	m.pathQuery = nil
+	m.pathHits = nil
+	m.refreshPathQuery()
+}
+
+// refreshPathQuery re-runs the path query after the content changed and
+// rebuilds the displayed JSON lines
+func (m *Model) refreshPathQuery() {
+	m.pathHits = nil
+	if m.pathQuery != nil {
+		m.pathHits = m.pathQuery.Run(m.records)
+		sort.SliceStable(m.pathHits, func(i, j int) bool {
+			return m.pathHits[i].Line < m.pathHits[j].Line
+		})
+	}
+	m.applyRecordFilter()
+}
+
+// applyRecordFilter sets the displayed JSON lines: every record, or only the
+// records with path hits when pathOnly is on, with folded nodes on one line.
+// The cursor stays on the same content line where possible.
+func (m *Model) applyRecordFilter() {
+	cursor := m.originLine(m.cursorLine)
+
+	filtered := m.pathOnly && m.pathQuery != nil
+	if !filtered && !m.folds().jsonFolded() {
+		m.rawLines = m.fullRawLines
+		m.highlightedLines = m.fullHighlightedLines
+		m.lineOrigin = nil
+		m.lineEnd = nil
+	} else {
+		matched := make(map[int]bool)
+		for _, hit := range m.pathHits {
+			matched[hit.Record] = true
+		}
+		m.rawLines = nil
+		m.highlightedLines = nil
+		m.lineOrigin = nil
+		m.lineEnd = nil
+		for r, rec := range m.records {
+			if filtered && !matched[r] {
+				continue
+			}
+			if len(m.rawLines) > 0 {
+				// Blank separator, attributed to the line before the record
+				m.rawLines = append(m.rawLines, "")
+				m.highlightedLines = append(m.highlightedLines, "")
+				m.lineOrigin = append(m.lineOrigin, rec.Start-1)
+				m.lineEnd = append(m.lineEnd, rec.Start)
+			}
+			m.appendRecordLines(r)
+		}
+	}
+
+	m.cursorLine = m.displayLine(cursor)
+	m.handleJSONNavigation(0, 1) // Clamp
+	m.refreshSearch()
+}
+
+// displayLine maps a line of the full content to the displayed JSON lines:
+// to its fold when it is folded away, and forward to the next shown line when
+// its record is hidden
+func (m Model) displayLine(line int) int {
+	if m.lineOrigin == nil {
+		return line
+	}
+	i := sort.SearchInts(m.lineOrigin, line)
+	if (i >= len(m.lineOrigin) || m.lineOrigin[i] != line) && i > 0 && line < m.lineEnd[i-1] {
+		i-- // Inside a fold
+	}
+	if i >= len(m.lineOrigin) {
+		i = len(m.lineOrigin) - 1
+	}
+	return i
+}
+
+// originLine maps a displayed JSON line to the full content
+func (m Model) originLine(line int) int {
+	if m.lineOrigin == nil || line < 0 {
+		return line
+	}
+	if line >= len(m.lineOrigin) {
+		return m.lineOrigin[len(m.lineOrigin)-1]
+	}
+	return m.lineOrigin[line]
+}
+
+// pathHitLines returns the displayed line of every hit, sorted
+func (m Model) pathHitLines() []int {
+	lines := make([]int, len(m.pathHits))
+	for i, hit := range m.pathHits {
+		lines[i] = m.displayLine(hit.Line)
+	}
+	return lines
+}
+
+// findNextPathHit moves the cursor to the next (direction > 0) or previous
+// path hit
+func (m *Model) findNextPathHit(direction int) {
+	if line, ok := nextHitLine(m.pathHitLines(), m.cursorLine, direction); ok {
+		m.cursorLine = line
+		m.ensureCursorVisible()
+	}
+}
+
+// togglePathOnly switches between showing every record and only the records
+// with path hits
+func (m *Model) togglePathOnly() {
+	if m.pathQuery == nil {
+		m.statusMessage = "Run a path query with | first"
+		return
+	}
+	m.pathOnly = !m.pathOnly
+	m.applyRecordFilter()
+	if m.pathOnly {
+		m.statusMessage = "Showing only matching records"
+	} else {
+		m.statusMessage = "Showing all records"
+	}
+}
+
+// pathIndicator renders the header's path query status, e.g. "[|.type 3/17]"
+func (m Model) pathIndicator() string {
+	if m.pathQuery == nil {
+		return ""
+	}
+	pos := "-"
+	if n := hitPosition(m.pathHitLines(), m.cursorLine); n > 0 {
+		pos = fmt.Sprint(n)
+	}
+	only := ""
+	if m.pathOnly {
+		only = " only"
+	}
+	return "  " + searchStyle.Render(fmt.Sprintf("[|%s %s/%d%s]", m.pathQueryText, pos, len(m.pathHits), only))
+}
+
+// buildPathPanel lists the path hits in place of the preview pane. The hit on
+// the cursor line is selected and kept in view.
+func (m Model) buildPathPanel(width, height int) []string {
+	records := make(map[int]bool)
+	for _, hit := range m.pathHits {
+		records[hit.Record] = true
+	}
+	lines := []string{
+		titleStyle.Render(truncateText(m.pathQueryText, width-2)),
+		helpStyle.Render(fmt.Sprintf("%d values in %d records", len(m.pathHits), len(records))),
+		strings.Repeat("─", width-2),
+	}
+	if len(m.pathHits) == 0 {
+		return append(lines, noPreviewStyle.Render("No values"))
+	}
+
+	hitLines := m.pathHitLines()
+	current := sort.SearchInts(hitLines, m.cursorLine)
+	if current >= len(hitLines) {
+		current = len(hitLines) - 1
+	}
+
+	rows := height - len(lines)
+	if rows < 1 {
+		rows = 1
+	}
+	start := current - rows/2
+	if start > len(m.pathHits)-rows {
+		start = len(m.pathHits) - rows
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	for i := start; i < len(m.pathHits) && i < start+rows; i++ {
+		hit := m.pathHits[i]
+		row := fmt.Sprintf("%6d  %s = %s", hit.Line+1, hit.Path, formatPathValue(hit.Value))
+		row = truncateText(row, width-2)
+		if hitLines[i] == m.cursorLine {
+			lines = append(lines, selectedStyle.Render(row))
+		} else {
+			lines = append(lines, normalStyle.Render(row))
+		}
+	}
+	return lines
+}
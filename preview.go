@@ -6,10 +6,15 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/wordwrap"
 )
 
+// markdownStyle is the glamour style Markdown is rendered with, set by the
+// theme
+var markdownStyle = styles.AutoStyle
+
 var (
 	// Style for the preview pane header
 	previewHeaderStyle = lipgloss.NewStyle().
@@ -71,15 +76,15 @@ func unescapeJSONString(s string) string {
 	return result
 }
 
-// RenderPreview extracts a string from the line and renders it as markdown
+// RenderPreview extracts a string from the line and renders it for its
+// content type
 func RenderPreview(line string, width int) PreviewResult {
 	key, value, found := ExtractStringFromLine(line)
 	if !found {
 		return PreviewResult{IsString: false}
 	}
 
-	// Render as markdown
-	rendered := renderMarkdown(value, width)
+	rendered := renderText(value, width)
 
 	return PreviewResult{
 		Key:      key,
@@ -89,50 +94,15 @@ func RenderPreview(line string, width int) PreviewResult {
 	}
 }
 
-// looksLikeMarkdown checks if content appears to contain markdown formatting
-func looksLikeMarkdown(content string) bool {
-	// Check for common markdown indicators
-	markdownPatterns := []string{
-		"```",      // Code blocks
-		"**",       // Bold
-		"__",       // Bold
-		"*",        // Italic (but be careful with bullet points)
-		"_",        // Italic
-		"# ",       // Headers
-		"## ",      // Headers
-		"### ",     // Headers
-		"- ",       // Lists
-		"* ",       // Lists
-		"1. ",      // Numbered lists
-		"[",        // Links
-		"`",        // Inline code
-		"> ",       // Blockquotes
-		"---",      // Horizontal rules
-		"***",      // Horizontal rules
-	}
-
-	for _, pattern := range markdownPatterns {
-		if strings.Contains(content, pattern) {
-			return true
-		}
-	}
-	return false
-}
-
 // renderMarkdown renders a string as markdown for terminal display
-// Falls back to plain text word wrapping for non-markdown content
+// Falls back to plain text word wrapping if glamour fails
 func renderMarkdown(content string, width int) string {
 	if width < 20 {
 		width = 20
 	}
 
-	// If it doesn't look like markdown, just word wrap it
-	if !looksLikeMarkdown(content) {
-		return wordwrap.String(content, width)
-	}
-
 	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
+		glamour.WithStandardStyle(markdownStyle),
 		glamour.WithWordWrap(width),
 	)
 	if err != nil {
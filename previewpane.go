@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bounds and step of the JSON pane's share of the width, in percent
+const (
+	defaultJSONPanePercent = 55
+	minJSONPanePercent     = 20
+	maxJSONPanePercent     = 80
+	jsonPaneStep           = 5
+)
+
+// jsonPanePercent returns the JSON pane's share of the width
+func (m Model) jsonPanePercent() int {
+	if m.jsonPaneSplit == 0 {
+		return defaultJSONPanePercent
+	}
+	return m.jsonPaneSplit
+}
+
+// resizePanes widens (direction > 0) or narrows the JSON pane
+func (m *Model) resizePanes(count, direction int) {
+	percent := m.jsonPanePercent() + count*direction*jsonPaneStep
+	m.jsonPaneSplit = max(minJSONPanePercent, min(maxJSONPanePercent, percent))
+	m.statusMessage = fmt.Sprintf("JSON pane %d%%", m.jsonPaneSplit)
+}
+
+// previewWidth returns the width the preview is rendered at
+func (m Model) previewWidth() int {
+	if m.previewFull {
+		return m.width
+	}
+	return m.rightPaneWidth()
+}
+
+// previewCache keeps the preview of one line, so that drawing, scrolling
+// and searching it don't render it again. It is replaced whenever the
+// session's lines are.
+type previewCache struct {
+	valid       bool
+	line, width int
+	title       string
+	body        []string
+	ok          bool
+
+	pattern *SearchPattern // What hits were found with; nil before a search
+	hits    []int
+}
+
+// previewContent returns the preview of the JSON node at the cursor, or for
+// lines outside the record trees the string on the line: a title and the
+// rendered body, or ok false when there is nothing to preview
+func (m Model) previewContent(width int) (title string, body []string, ok bool) {
+	if m.cursorLine >= len(m.rawLines) {
+		return "", nil, false
+	}
+	c, line := m.previewCache, m.originLine(m.cursorLine)
+	if c != nil && c.valid && c.line == line && c.width == width {
+		return c.title, c.body, c.ok
+	}
+	title, body, ok = m.renderPreview(width)
+	if c != nil {
+		*c = previewCache{valid: true, line: line, width: width, title: title, body: body, ok: ok}
+	}
+	return title, body, ok
+}
+
+// renderPreview renders what previewContent returns
+func (m Model) renderPreview(width int) (title string, body []string, ok bool) {
+	if title, rendered, ok := m.nodePreview(width - 2); ok { // -2 for padding
+		return title, strings.Split(rendered, "\n"), true
+	}
+	preview := RenderPreview(m.rawLines[m.cursorLine], width-2)
+	if !preview.IsString {
+		return "", nil, false
+	}
+	title = preview.Key
+	if title == "" {
+		title = "String Value"
+	}
+	return title, strings.Split(preview.Rendered, "\n"), true
+}
+
+// previewOffset returns the preview's scroll position. It belongs to the line
+// it was scrolled on, so moving the JSON cursor starts the next preview at
+// the top.
+func (m Model) previewOffset() int {
+	if m.previewAt != m.originLine(m.cursorLine) {
+		return 0
+	}
+	return m.previewScroll
+}
+
+// previewBodyHeight returns how many body lines fit under the preview title
+func (m Model) previewBodyHeight() int {
+	return max(1, m.viewerHeight()-2)
+}
+
+// scrollPreview scrolls the preview body by count lines, or to an absolute
+// line with direction 0, keeping it within the body
+func (m *Model) scrollPreview(count, direction int) {
+	_, body, ok := m.previewContent(m.previewWidth())
+	if !ok {
+		return
+	}
+	offset := m.previewOffset() + count*direction
+	if direction == 0 {
+		offset = count
+	}
+	m.previewAt = m.originLine(m.cursorLine)
+	m.previewScroll = max(0, min(offset, len(body)-m.previewBodyHeight()))
+}
+
+// togglePreviewFocus moves keyboard focus into the preview pane or back out
+func (m *Model) togglePreviewFocus() {
+	if m.pathQuery != nil {
+		m.statusMessage = "Close the path query (esc) to use the preview"
+		return
+	}
+	m.previewFocus = !m.previewFocus
+	if !m.previewFocus {
+		m.previewFull = false
+	}
+}
+
+// togglePreviewFull shows the preview full-screen, with focus, or returns to
+// the split
+func (m *Model) togglePreviewFull() {
+	if m.pathQuery != nil {
+		m.statusMessage = "Close the path query (esc) to use the preview"
+		return
+	}
+	m.previewFull = !m.previewFull
+	m.previewFocus = m.previewFull
+}
+
+// applyPreviewSearch searches the preview body and jumps to the first match
+func (m *Model) applyPreviewSearch(query string) {
+	m.previewQuery = query
+	m.previewPattern = nil
+	m.previewErr = ""
+	if query == "" {
+		return
+	}
+	pattern, err := CompileSearch(query, m.searchOpts)
+	if err != nil {
+		m.previewErr = "Invalid regex: " + err.Error()
+		return
+	}
+	m.previewPattern = pattern
+	m.findNextInPreview(1)
+}
+
+// previewHits returns the body lines of the preview that match its search
+func (m Model) previewHits() []int {
+	if m.previewPattern == nil {
+		return nil
+	}
+	_, body, ok := m.previewContent(m.previewWidth())
+	if !ok {
+		return nil
+	}
+	c := m.previewCache
+	if c != nil && c.pattern == m.previewPattern {
+		return c.hits
+	}
+	hits := searchHitLines(body, m.previewPattern)
+	if c != nil {
+		c.pattern, c.hits = m.previewPattern, hits
+	}
+	return hits
+}
+
+// findNextInPreview scrolls the next preview match to the top
+func (m *Model) findNextInPreview(direction int) {
+	if line, ok := nextHitLine(m.previewHits(), m.previewOffset(), direction); ok {
+		m.scrollPreview(line, 0)
+	} else if m.previewPattern != nil {
+		m.statusMessage = "No matches in the preview"
+	}
+}
+
+// handlePreviewKeys handles keys while the preview pane has focus. It reports
+// whether the key was used; other keys act on the viewer as usual.
+func (m *Model) handlePreviewKeys(key string, count int) bool {
+	page := m.previewBodyHeight()
+	switch key {
+	case "j", "down":
+		m.scrollPreview(count, 1)
+	case "k", "up":
+		m.scrollPreview(count, -1)
+	case "ctrl+d":
+		m.scrollPreview(count*page/2, 1)
+	case "ctrl+u":
+		m.scrollPreview(count*page/2, -1)
+	case "ctrl+f", " ":
+		m.scrollPreview(count*page, 1)
+	case "ctrl+b":
+		m.scrollPreview(count*page, -1)
+	case "g":
+		if m.lastKey != "g" {
+			m.lastKey = "g"
+			return true
+		}
+		m.scrollPreview(0, 0)
+	case "G":
+		m.scrollPreview(1<<30, 0)
+	case "n":
+		m.findNextInPreview(1)
+	case "N":
+		m.findNextInPreview(-1)
+	case "/":
+		m.searchMode = true
+		m.searchPrompt = "/"
+		m.searchInput = ""
+	case "esc":
+		switch {
+		case m.previewQuery != "":
+			m.applyPreviewSearch("")
+		case m.previewFull:
+			m.togglePreviewFull()
+		default:
+			m.previewFocus = false
+		}
+	case "p":
+		m.togglePreviewFocus()
+	default:
+		return false
+	}
+	m.lastKey = ""
+	return true
+}
+
+// buildRightPane renders the preview: its title, scroll position and the
+// visible part of the body, with preview search matches highlighted
+func (m Model) buildRightPane(width, height int) []string {
+	title, body, ok := m.previewContent(width)
+	if !ok {
+		msg := noPreviewStyle.Render("(no string value)")
+		return centerLines([]string{msg}, height)
+	}
+
+	offset := min(m.previewOffset(), max(0, len(body)-m.previewBodyHeight()))
+	end := min(len(body), offset+height-2)
+
+	info := ""
+	if len(body) > height-2 {
+		info = fmt.Sprintf("%d–%d/%d", offset+1, end, len(body))
+	}
+	if m.previewQuery != "" {
+		info += fmt.Sprintf("  [/%s %d]", m.previewQuery, len(m.previewHits()))
+	}
+	header := titleStyle.Render(title)
+	if m.previewFocus {
+		header = selectedStyle.Render(" " + title + " ")
+	}
+	header = padOrTruncate(header, max(0, width-2-len([]rune(info)))) + helpStyle.Render(info)
+
+	lines := []string{header, strings.Repeat("─", max(0, width-2))}
+	for _, line := range body[offset:end] {
+		if m.previewPattern != nil {
+			line = HighlightPattern(line, m.previewPattern)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewScrollAndSearch(t *testing.T) {
+	var paragraphs []string
+	for i := 0; i < 40; i++ {
+		paragraphs = append(paragraphs, "Paragraph "+strings.Repeat("x", i%3+1))
+	}
+	paragraphs[30] = "The needle is here"
+	text, _ := json.Marshal(strings.Join(paragraphs, "\n\n"))
+	record := `{"type":"user","message":{"content":` + string(text) + `}}`
+
+	testFile := filepath.Join(t.TempDir(), "test.jsonl")
+	if err := os.WriteFile(testFile, []byte(record), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	m := Model{width: 100, height: 20}
+	if err := m.openSession(testFile); err != nil {
+		t.Fatal(err)
+	}
+	m.viewMode = ViewModeJSON
+	for m.cursorLine < len(m.rawLines) && !strings.Contains(m.rawLines[m.cursorLine], `"content"`) {
+		m.cursorLine++
+	}
+
+	m.togglePreviewFocus()
+	m.handlePreviewKeys("G", 1)
+	_, body, _ := m.previewContent(m.previewWidth())
+	if want := len(body) - m.previewBodyHeight(); m.previewOffset() != want {
+		t.Errorf("G scrolled to %d, want %d", m.previewOffset(), want)
+	}
+	m.handlePreviewKeys("j", 5)
+	if want := len(body) - m.previewBodyHeight(); m.previewOffset() != want {
+		t.Errorf("Scrolled past the end to %d", m.previewOffset())
+	}
+
+	m.applyPreviewSearch("needle")
+	if hits := m.previewHits(); len(hits) != 1 || m.previewOffset() != hits[0] {
+		t.Errorf("Search hits %v, offset %d", hits, m.previewOffset())
+	}
+	if pane := m.buildRightPane(m.rightPaneWidth(), m.viewerHeight()); !strings.Contains(StripAnsi(pane[2]), "needle") {
+		t.Errorf("Match not at the top of the pane: %q", StripAnsi(pane[2]))
+	}
+
+	// The body is rendered once and reused until the line or width changes
+	_, again, _ := m.previewContent(m.previewWidth())
+	if &again[0] != &body[0] {
+		t.Error("The preview was rendered again for the same line")
+	}
+	if m.previewCache.pattern != m.previewPattern {
+		t.Error("Preview hits weren't kept")
+	}
+	if _, narrow, _ := m.previewContent(m.previewWidth() - 10); &narrow[0] == &body[0] {
+		t.Error("A new width should render the preview again")
+	}
+
+	// Another line's preview starts at the top
+	m.cursorLine--
+	if m.previewOffset() != 0 {
+		t.Errorf("Moving the cursor kept offset %d", m.previewOffset())
+	}
+	if _, other, ok := m.previewContent(m.previewWidth()); ok && &other[0] == &body[0] {
+		t.Error("Another line should have its own preview")
+	}
+}
+
+func TestResizePanes(t *testing.T) {
+	m := Model{width: 100}
+	if m.leftPaneWidth() != 55 {
+		t.Errorf("Default JSON pane width %d, want 55", m.leftPaneWidth())
+	}
+	m.resizePanes(2, 1)
+	if m.leftPaneWidth() != 65 {
+		t.Errorf("Widened JSON pane to %d, want 65", m.leftPaneWidth())
+	}
+	m.resizePanes(20, -1)
+	if m.leftPaneWidth() != minJSONPanePercent {
+		t.Errorf("Narrowed JSON pane to %d, want %d", m.leftPaneWidth(), minJSONPanePercent)
+	}
+}
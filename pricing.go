@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelPricing is what a model charges, in USD per million tokens
+type ModelPricing struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// DefaultPricing returns the list prices of Claude models, by model ID
+// prefix. The config file can add models or override these.
+func DefaultPricing() map[string]ModelPricing {
+	return map[string]ModelPricing{
+		"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5},
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.5},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.1},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+		"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5},
+	}
+}
+
+// priceFor returns the pricing of the longest prefix of model in the table
+func priceFor(pricing map[string]ModelPricing, model string) (ModelPricing, bool) {
+	best := ""
+	for prefix := range pricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelPricing{}, false
+	}
+	return pricing[best], true
+}
+
+// sessionCost estimates what the assistant responses of a session cost from
+// their token usage. A response split over several records is counted once.
+// unpriced lists the models missing from the table.
+func sessionCost(messages []Message, pricing map[string]ModelPricing) (cost float64, unpriced []string) {
+	seen := make(map[string]bool)
+	missing := make(map[string]bool)
+	for _, msg := range messages {
+		if msg.Type != "assistant" {
+			continue
+		}
+		inner, _ := msg.Raw["message"].(map[string]interface{})
+		usage, _ := inner["usage"].(map[string]interface{})
+		model, _ := inner["model"].(string)
+		if usage == nil || model == "" || model == "<synthetic>" {
+			continue
+		}
+		if id, _ := inner["id"].(string); id != "" {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+
+		price, ok := priceFor(pricing, model)
+		if !ok {
+			if !missing[model] {
+				missing[model] = true
+				unpriced = append(unpriced, model)
+			}
+			continue
+		}
+		tokens := func(key string) float64 {
+			n, _ := usage[key].(float64)
+			return n
+		}
+		cost += (tokens("input_tokens")*price.Input +
+			tokens("output_tokens")*price.Output +
+			tokens("cache_creation_input_tokens")*price.CacheWrite +
+			tokens("cache_read_input_tokens")*price.CacheRead) / 1e6
+	}
+	return cost, unpriced
+}
+
+// updateCost estimates the cost of the open session with the configured
+// pricing, or the list prices when there is none
+func (m *Model) updateCost() {
+	pricing := m.pricing
+	if pricing == nil {
+		pricing = DefaultPricing()
+	}
+	m.cost, m.unpriced = sessionCost(m.parsedMessages, pricing)
+}
+
+// costIndicator renders the estimated cost of the open session for the
+// header, e.g. "$1.24", or "" when it has no token usage
+func (m Model) costIndicator() string {
+	if m.cost == 0 && len(m.unpriced) == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("$%.2f", m.cost)
+	if len(m.unpriced) > 0 {
+		text += " + unpriced " + strings.Join(m.unpriced, ",")
+	}
+	return "  " + helpStyle.Render(text)
+}
+
+func validatePricing(pricing map[string]ModelPricing) error {
+	for _, model := range sortedKeys(pricing) {
+		if model == "" {
+			return fmt.Errorf("pricing: empty model ID")
+		}
+		p := pricing[model]
+		for _, field := range []struct {
+			name  string
+			price float64
+		}{{"input", p.Input}, {"output", p.Output}, {"cache_write", p.CacheWrite}, {"cache_read", p.CacheRead}} {
+			if field.price < 0 {
+				return fmt.Errorf("pricing[%q].%s: %g is negative", model, field.name, field.price)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSessionCost(t *testing.T) {
+	assistant := func(id, model string, input, output float64) Message {
+		return Message{Type: "assistant", Raw: map[string]interface{}{
+			"message": map[string]interface{}{
+				"id":    id,
+				"model": model,
+				"usage": map[string]interface{}{"input_tokens": input, "output_tokens": output, "cache_read_input_tokens": 1e6},
+			},
+		}}
+	}
+	messages := []Message{
+		{Type: "user"},
+		assistant("m1", "claude-sonnet-4-20250514", 1e6, 1e6),
+		assistant("m1", "claude-sonnet-4-20250514", 1e6, 1e6), // Same response, another block
+		assistant("m2", "claude-opus-4-5-20251101", 0, 1e6),
+		assistant("m3", "gpt-x", 1e6, 0),
+		assistant("m4", "<synthetic>", 1e6, 0),
+	}
+
+	cost, unpriced := sessionCost(messages, DefaultPricing())
+	want := (3 + 15 + 0.3) + (25 + 0.5) // Opus 4.5 rather than Opus 4
+	if math.Abs(cost-want) > 1e-9 {
+		t.Errorf("cost = %v, want %v", cost, want)
+	}
+	if len(unpriced) != 1 || unpriced[0] != "gpt-x" {
+		t.Errorf("unpriced = %v", unpriced)
+	}
+}
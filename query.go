@@ -0,0 +1,512 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query is a parsed structured message query, e.g.
+//
+//	role:assistant tool:Bash "npm test" after:2026-09-01 is:error has:thinking
+//
+// Terms are ANDed; OR, NOT / -term and parentheses are supported.
+type Query struct {
+	root  queryNode
+	terms []string // Free-text terms, for highlighting
+}
+
+// QueryError is a parse error with the column it occurred at
+type QueryError struct {
+	Pos int // 0-based byte offset into the query
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("col %d: %s", e.Pos+1, e.Msg)
+}
+
+// queryFields describes each field and, for enumerated fields, its values
+var queryFields = map[string][]string{
+	"role":   {"user", "assistant", "system", "summary", "tool"},
+	"type":   nil,
+	"tool":   nil,
+	"after":  nil,
+	"before": nil,
+	"uuid":   nil,
+	"is":     {"error", "meta", "prompt", "tool_result", "sidechain"},
+	"has":    {"text", "thinking", "tool_use", "tool_result"},
+}
+
+// knownFieldList returns the field names for error messages
+func knownFieldList() string {
+	var names []string
+	for name := range queryFields {
+		names = append(names, name+":")
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+// LooksLikeQuery reports whether s uses structured query syntax, i.e. has at
+// least one known field:value predicate. Plain text searches don't.
+func LooksLikeQuery(s string) bool {
+	toks, err := lexQuery(s)
+	if err != nil {
+		return false
+	}
+	for _, tok := range toks {
+		if tok.kind == qtokField {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseQuery parses a structured query
+func ParseQuery(s string) (*Query, error) {
+	toks, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, &QueryError{Pos: 0, Msg: "empty query"}
+	}
+
+	p := &queryParser{toks: toks, end: len(s)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		tok := p.toks[p.pos]
+		if tok.kind == qtokRParen {
+			return nil, &QueryError{Pos: tok.pos, Msg: "unmatched )"}
+		}
+		return nil, &QueryError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+	return &Query{root: root, terms: p.terms}, nil
+}
+
+// Match reports whether msg satisfies the query
+func (q *Query) Match(msg *Message) bool {
+	return q.root.eval(msg)
+}
+
+// TextTerms returns the free-text terms and phrases in the query
+func (q *Query) TextTerms() []string {
+	return q.terms
+}
+
+// HighlightPattern returns a search pattern matching the query's free-text
+// terms, or nil if it has none
+func (q *Query) HighlightPattern() *SearchPattern {
+	if len(q.terms) == 0 {
+		return nil
+	}
+	var quoted []string
+	for _, term := range q.terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	p, err := CompileSearch(strings.Join(quoted, "|"), SearchOptions{Regex: true, Case: CaseInsensitive})
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// FormatMatches lists the messages of a session that match the query, one
+// tab-separated line each, for the -query flag
+func (q *Query) FormatMatches(sessionPath string, messages []Message) string {
+	var b strings.Builder
+	for i := range messages {
+		msg := &messages[i]
+		if !q.Match(msg) {
+			continue
+		}
+		ts := ""
+		if !msg.Timestamp.IsZero() {
+			ts = msg.Timestamp.Local().Format("2006-01-02 15:04:05")
+		}
+		text := truncateText(firstLine(strings.TrimSpace(msg.PlainText())), 100)
+		fmt.Fprintf(&b, "%s\tmsg %d\t%s\t%s\t%s\t%s\n",
+			sessionPath, i+1, msg.UUID, ts, indexRole(msg), text)
+	}
+	return b.String()
+}
+
+// Lexer
+
+type qtokKind int
+
+const (
+	qtokWord   qtokKind = iota // Bare word
+	qtokPhrase                 // "quoted phrase"
+	qtokField                  // field:value
+	qtokLParen
+	qtokRParen
+	qtokNot // - prefix or NOT
+	qtokOr
+	qtokAnd
+)
+
+type qtok struct {
+	kind  qtokKind
+	text  string // Word/phrase text, or field name for qtokField
+	value string // Field value
+	pos   int
+}
+
+func lexQuery(s string) ([]qtok, error) {
+	var toks []qtok
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '(':
+			toks = append(toks, qtok{kind: qtokLParen, text: "(", pos: i})
+			i++
+
+		case c == ')':
+			toks = append(toks, qtok{kind: qtokRParen, text: ")", pos: i})
+			i++
+
+		case c == '-' && i+1 < len(s) && s[i+1] != ' ':
+			toks = append(toks, qtok{kind: qtokNot, text: "-", pos: i})
+			i++
+
+		case c == '"':
+			text, next, err := lexQuoted(s, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, qtok{kind: qtokPhrase, text: text, pos: i})
+			i = next
+
+		default:
+			start := i
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' && s[i] != '(' && s[i] != ')' && s[i] != '"' {
+				i++
+			}
+			word := s[start:i]
+
+			// field:value, where the value may be quoted
+			if name, value, ok := strings.Cut(word, ":"); ok && name != "" {
+				if _, known := queryFields[strings.ToLower(name)]; known {
+					if value == "" && i < len(s) && s[i] == '"' {
+						text, next, err := lexQuoted(s, i)
+						if err != nil {
+							return nil, err
+						}
+						value, i = text, next
+					}
+					toks = append(toks, qtok{kind: qtokField, text: strings.ToLower(name), value: value, pos: start})
+					continue
+				}
+			}
+
+			switch word {
+			case "OR", "|":
+				toks = append(toks, qtok{kind: qtokOr, text: word, pos: start})
+			case "AND":
+				toks = append(toks, qtok{kind: qtokAnd, text: word, pos: start})
+			case "NOT":
+				toks = append(toks, qtok{kind: qtokNot, text: word, pos: start})
+			default:
+				toks = append(toks, qtok{kind: qtokWord, text: word, pos: start})
+			}
+		}
+	}
+	return toks, nil
+}
+
+// lexQuoted reads a double-quoted string starting at s[start]
+func lexQuoted(s string, start int) (string, int, error) {
+	var b strings.Builder
+	i := start + 1
+	for i < len(s) {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				b.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return "", 0, &QueryError{Pos: start, Msg: "unterminated quote"}
+}
+
+// Parser
+
+type queryParser struct {
+	toks  []qtok
+	pos   int
+	end   int // Length of the source, for end-of-input errors
+	terms []string
+}
+
+func (p *queryParser) peek() *qtok {
+	if p.pos < len(p.toks) {
+		return &p.toks[p.pos]
+	}
+	return nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []queryNode{left}
+	for tok := p.peek(); tok != nil && tok.kind == qtokOr; tok = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return orNode(nodes), nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var nodes []queryNode
+	for {
+		tok := p.peek()
+		if tok == nil || tok.kind == qtokOr || tok.kind == qtokRParen {
+			break
+		}
+		if tok.kind == qtokAnd {
+			p.pos++
+			continue
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		pos := p.end
+		if tok := p.peek(); tok != nil {
+			pos = tok.pos
+		}
+		return nil, &QueryError{Pos: pos, Msg: "expected a search term"}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return andNode(nodes), nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	tok := p.peek()
+	if tok.kind == qtokNot {
+		p.pos++
+		if p.peek() == nil {
+			return nil, &QueryError{Pos: tok.pos, Msg: "nothing to negate"}
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	tok := p.peek()
+	p.pos++
+
+	switch tok.kind {
+	case qtokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.kind != qtokRParen {
+			return nil, &QueryError{Pos: tok.pos, Msg: "unclosed ("}
+		}
+		p.pos++
+		return node, nil
+
+	case qtokWord, qtokPhrase:
+		p.terms = append(p.terms, tok.text)
+		return textNode(strings.ToLower(tok.text)), nil
+
+	case qtokField:
+		return parseField(tok)
+
+	default:
+		return nil, &QueryError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+}
+
+// parseField builds the predicate for a field:value token
+func parseField(tok *qtok) (queryNode, error) {
+	value := tok.value
+	if value == "" {
+		return nil, &QueryError{Pos: tok.pos, Msg: fmt.Sprintf("%s: needs a value", tok.text)}
+	}
+	lower := strings.ToLower(value)
+
+	if allowed := queryFields[tok.text]; allowed != nil {
+		valid := false
+		for _, a := range allowed {
+			if a == lower {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, &QueryError{Pos: tok.pos, Msg: fmt.Sprintf("unknown %s: value %q (expected one of %s)",
+				tok.text, value, strings.Join(allowed, ", "))}
+		}
+	}
+
+	switch tok.text {
+	case "role":
+		return predicateNode(func(m *Message) bool { return indexRole(m) == lower }), nil
+
+	case "type":
+		return predicateNode(func(m *Message) bool { return strings.ToLower(m.Type) == lower }), nil
+
+	case "uuid":
+		return predicateNode(func(m *Message) bool { return strings.HasPrefix(m.UUID, value) }), nil
+
+	case "tool":
+		return predicateNode(func(m *Message) bool {
+			for _, block := range m.Content {
+				if block.Type != "tool_use" {
+					continue
+				}
+				if ok, _ := path.Match(lower, strings.ToLower(block.Name)); ok {
+					return true
+				}
+			}
+			return false
+		}), nil
+
+	case "after", "before":
+		t, err := parseQueryTime(value)
+		if err != nil {
+			return nil, &QueryError{Pos: tok.pos, Msg: fmt.Sprintf("%s: expected a date like 2026-09-01, got %q", tok.text, value)}
+		}
+		if tok.text == "after" {
+			return predicateNode(func(m *Message) bool { return !m.Timestamp.IsZero() && !m.Timestamp.Before(t) }), nil
+		}
+		return predicateNode(func(m *Message) bool { return !m.Timestamp.IsZero() && m.Timestamp.Before(t) }), nil
+
+	case "is":
+		switch lower {
+		case "error":
+			return predicateNode(func(m *Message) bool {
+				for _, block := range m.Content {
+					if block.IsError {
+						return true
+					}
+				}
+				return false
+			}), nil
+		case "meta":
+			return predicateNode(func(m *Message) bool { return m.IsMeta }), nil
+		case "prompt":
+			return predicateNode(func(m *Message) bool { return isUserPrompt(m) }), nil
+		case "tool_result":
+			return predicateNode(func(m *Message) bool { return m.HasToolResult() }), nil
+		case "sidechain":
+			return predicateNode(func(m *Message) bool {
+				sidechain, _ := m.Raw["isSidechain"].(bool)
+				return sidechain
+			}), nil
+		}
+
+	case "has":
+		return predicateNode(func(m *Message) bool {
+			for _, block := range m.Content {
+				if block.Type == lower {
+					return true
+				}
+			}
+			return false
+		}), nil
+	}
+
+	return nil, &QueryError{Pos: tok.pos, Msg: fmt.Sprintf("unknown field %q (known: %s)", tok.text, knownFieldList())}
+}
+
+// parseQueryTime accepts a date (local time) or a full RFC 3339 timestamp
+func parseQueryTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// Evaluator
+
+type queryNode interface {
+	eval(msg *Message) bool
+}
+
+type andNode []queryNode
+
+func (n andNode) eval(msg *Message) bool {
+	for _, child := range n {
+		if !child.eval(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []queryNode
+
+func (n orNode) eval(msg *Message) bool {
+	for _, child := range n {
+		if child.eval(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	child queryNode
+}
+
+func (n notNode) eval(msg *Message) bool {
+	return !n.child.eval(msg)
+}
+
+// textNode matches a lowercased term anywhere in the message's text
+type textNode string
+
+func (n textNode) eval(msg *Message) bool {
+	for _, block := range msg.Content {
+		if strings.Contains(strings.ToLower(block.Content), string(n)) {
+			return true
+		}
+	}
+	return false
+}
+
+type predicateNode func(msg *Message) bool
+
+func (n predicateNode) eval(msg *Message) bool {
+	return n(msg)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func queryTestMessages() []Message {
+	ts := func(s string) time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+	return []Message{
+		{Type: "user", UUID: "u1", Timestamp: ts("2026-08-30T10:00:00Z"),
+			Content: []ContentBlock{{Type: "text", Content: "Please run npm test"}}},
+		{Type: "assistant", UUID: "a1", Timestamp: ts("2026-09-02T10:00:00Z"),
+			Content: []ContentBlock{
+				{Type: "thinking", Content: "Tests first"},
+				{Type: "tool_use", Name: "Bash", Content: `{"command": "npm test"}`},
+			}},
+		{Type: "user", UUID: "t1", Timestamp: ts("2026-09-02T10:00:05Z"),
+			Content: []ContentBlock{{Type: "tool_result", Content: "1 failing", IsError: true}}},
+		{Type: "assistant", UUID: "a2", Timestamp: ts("2026-09-02T10:01:00Z"),
+			Content: []ContentBlock{{Type: "tool_use", Name: "Read", Content: `{"file_path": "main.go"}`}}},
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	messages := queryTestMessages()
+
+	tests := []struct {
+		query string
+		want  string // UUIDs of matching messages
+	}{
+		{`role:assistant`, "a1 a2"},
+		{`role:user`, "u1"},
+		{`role:tool is:error`, "t1"},
+		{`tool:Bash "npm test"`, "a1"},
+		{`tool:b*`, "a1"},
+		{`has:thinking after:2026-09-01`, "a1"},
+		{`before:2026-09-01`, "u1"},
+		{`tool:Bash OR tool:Read`, "a1 a2"},
+		{`role:assistant -tool:Bash`, "a2"},
+		{`role:assistant NOT (has:thinking)`, "a2"},
+		{`(role:user | role:tool) npm`, "u1"},
+		{`uuid:a`, "a1 a2"},
+		{`role:assistant tool:Bash "npm test" after:2026-09-01 has:thinking`, "a1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) failed: %v", tt.query, err)
+			}
+			var got []string
+			for i := range messages {
+				if q.Match(&messages[i]) {
+					got = append(got, messages[i].UUID)
+				}
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("matched %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`is:broken`, `col 1: unknown is: value "broken"`},
+		{`role:`, "col 1: role: needs a value"},
+		{`(role:user`, "col 1: unclosed ("},
+		{`role:user)`, "col 10: unmatched )"},
+		{`"npm test`, "col 1: unterminated quote"},
+		{`after:yesterday`, "col 1: after: expected a date"},
+		{`role:user OR`, "col 13: expected a search term"},
+		{`foo NOT`, "col 5: nothing to negate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			if err == nil {
+				t.Fatalf("ParseQuery(%q) should fail", tt.query)
+			}
+			if !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("error = %q, want prefix %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikeQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"role:user", true},
+		{`tool:"Bash"`, true},
+		{"npm test", false},
+		{"http://example.com", false},
+		{"key: value", false},
+	}
+	for _, tt := range tests {
+		if got := LooksLikeQuery(tt.input); got != tt.want {
+			t.Errorf("LooksLikeQuery(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestQueryHighlightPattern(t *testing.T) {
+	q, err := ParseQuery(`role:assistant "npm test" a.b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := q.HighlightPattern()
+	if p == nil {
+		t.Fatal("Expected a highlight pattern for free-text terms")
+	}
+	if got := len(p.FindAll("NPM TEST a.b axb")); got != 2 {
+		t.Errorf("found %d matches, want 2", got)
+	}
+
+	q, _ = ParseQuery("role:user")
+	if q.HighlightPattern() != nil {
+		t.Error("Queries without free text should not highlight")
+	}
+}
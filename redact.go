@@ -0,0 +1,279 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Detector finds one kind of secret in text
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate, if set, filters out matches that aren't plausibly secrets
+	Validate func(string) bool
+}
+
+// Built-in detectors. Patterns with a capture group redact only the group,
+// so the surrounding key name stays readable.
+var builtinDetectors = []Detector{
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{Name: "aws-access-key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)},
+	{Name: "aws-secret-key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Name: "anthropic-key", Pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{Name: "openai-key", Pattern: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9]{20,}\b`)},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9-]{10,}`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{Name: "secret-assignment", Pattern: regexp.MustCompile(`(?i)\b[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|PRIVATE_KEY)[A-Z0-9_]*["']?\s*[=:]\s*["']?([^\s"'\\,]{8,})`), Validate: looksLikePassword},
+	{Name: "high-entropy", Pattern: regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`), Validate: looksRandom},
+}
+
+// Redactor masks secrets found by its detectors
+type Redactor struct {
+	detectors []Detector
+}
+
+// Finding records where a secret was found in a session
+type Finding struct {
+	Detector     string
+	MessageIndex int
+	UUID         string
+	Timestamp    time.Time
+	Location     string // e.g. "assistant tool_use (Bash)"
+	Masked       string // The secret with all but a short prefix hidden
+}
+
+// secretMatch is a byte range in a string flagged by a detector
+type secretMatch struct {
+	Detector   string
+	Start, End int
+}
+
+// NewRedactor builds a redactor from the built-in detectors plus any
+// user-defined patterns
+func NewRedactor(custom []RedactionPattern) (*Redactor, error) {
+	r := &Redactor{detectors: append([]Detector(nil), builtinDetectors...)}
+	for _, p := range custom {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", p.Name, err)
+		}
+		r.detectors = append(r.detectors, Detector{Name: p.Name, Pattern: re})
+	}
+	return r, nil
+}
+
+// Fingerprint identifies the redactor's patterns, so text masked with other
+// patterns can be told apart; it is "" for no redaction
+func (r *Redactor) Fingerprint() string {
+	if r == nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, d := range r.detectors {
+		fmt.Fprintf(h, "%s\x00%s\x00", d.Name, d.Pattern)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// find returns the non-overlapping secret ranges in s, sorted by position
+func (r *Redactor) find(s string) []secretMatch {
+	var matches []secretMatch
+	for _, d := range r.detectors {
+		for _, loc := range d.Pattern.FindAllStringSubmatchIndex(s, -1) {
+			start, end := loc[0], loc[1]
+			// Redact only the first capture group when there is one
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if d.Validate != nil && !d.Validate(s[start:end]) {
+				continue
+			}
+			matches = append(matches, secretMatch{Detector: d.Name, Start: start, End: end})
+		}
+	}
+
+	// Earlier detectors win ties; longer matches win at the same position
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End > matches[j].End
+	})
+
+	var merged []secretMatch
+	for _, match := range matches {
+		if len(merged) > 0 && match.Start < merged[len(merged)-1].End {
+			last := &merged[len(merged)-1]
+			if match.End > last.End {
+				last.End = match.End
+			}
+			continue
+		}
+		merged = append(merged, match)
+	}
+	return merged
+}
+
+// Redact replaces every secret in s with a [REDACTED:<detector>] marker
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	matches := r.find(s)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, match := range matches {
+		b.WriteString(s[pos:match.Start])
+		b.WriteString("[REDACTED:" + match.Detector + "]")
+		pos = match.End
+	}
+	b.WriteString(s[pos:])
+	return b.String()
+}
+
+// RedactMessages returns copies of messages with secrets redacted from every
+// content block. The Raw maps are shared with the originals.
+func (r *Redactor) RedactMessages(messages []Message) []Message {
+	if r == nil {
+		return messages
+	}
+	result := make([]Message, len(messages))
+	for i, msg := range messages {
+		blocks := make([]ContentBlock, len(msg.Content))
+		for j, block := range msg.Content {
+			block.Content = r.Redact(block.Content)
+			block.Args = r.Redact(block.Args) // Commands show these rather than Content
+			block.Note = r.Redact(block.Note)
+			blocks[j] = block
+		}
+		msg.Content = blocks
+		result[i] = msg
+	}
+	return result
+}
+
+// Scan lists every secret found in messages
+func (r *Redactor) Scan(messages []Message) []Finding {
+	var findings []Finding
+	for i := range messages {
+		msg := &messages[i]
+		for _, block := range msg.Content {
+			location := msg.Type + " " + block.Type
+			if block.Name != "" {
+				location += " (" + block.Name + ")"
+			}
+			for _, match := range r.find(block.Content) {
+				findings = append(findings, Finding{
+					Detector:     match.Detector,
+					MessageIndex: i,
+					UUID:         msg.UUID,
+					Timestamp:    msg.Timestamp,
+					Location:     location,
+					Masked:       maskSecret(block.Content[match.Start:match.End]),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// maskSecret keeps just enough of a secret to identify it for rotation
+func maskSecret(s string) string {
+	s = firstLine(s)
+	runes := []rune(s)
+	keep := 4
+	if len(runes) <= keep*2 {
+		keep = len(runes) / 4
+	}
+	stars := len(runes) - keep
+	if stars > 12 {
+		stars = 12
+	}
+	return string(runes[:keep]) + strings.Repeat("*", stars)
+}
+
+// minSecretEntropy is the entropy, in bits per character, above which a long
+// token is treated as generated. Hex digests and UUIDs top out at 4.
+const minSecretEntropy = 4.3
+
+// looksRandom reports whether s has the character mix and entropy of a
+// generated secret rather than a word, path or hex digest
+func looksRandom(s string) bool {
+	mix := characterMix(s)
+	return mix.digit && mix.letter && shannonEntropy(s) >= minSecretEntropy
+}
+
+// looksLikePassword reports whether an assigned value is plausibly a
+// credential rather than a plain word or a number
+func looksLikePassword(s string) bool {
+	if strings.HasPrefix(s, "$") || strings.Trim(s, "*x") == "" {
+		return false // ${VAR} references and ******** placeholders
+	}
+	mix := characterMix(s)
+	return mix.letter && (mix.digit || mix.symbol || (mix.upper && mix.lower))
+}
+
+type charMix struct {
+	digit, letter, upper, lower, symbol bool
+}
+
+func characterMix(s string) charMix {
+	var mix charMix
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			mix.digit = true
+		case c >= 'a' && c <= 'z':
+			mix.letter, mix.lower = true, true
+		case c >= 'A' && c <= 'Z':
+			mix.letter, mix.upper = true, true
+		default:
+			mix.symbol = true
+		}
+	}
+	return mix
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, c := range s {
+		counts[c]++
+		total++
+	}
+	var entropy float64
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// FormatFindings renders a findings report, one line per secret
+func FormatFindings(sessionPath string, findings []Finding) string {
+	var b strings.Builder
+	for _, f := range findings {
+		ts := ""
+		if !f.Timestamp.IsZero() {
+			ts = f.Timestamp.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(&b, "%s\tmsg %d\t%s\t%s\t%s\t%s\t%s\n",
+			sessionPath, f.MessageIndex+1, f.UUID, ts, f.Location, f.Detector, f.Masked)
+	}
+	return b.String()
+}
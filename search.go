@@ -0,0 +1,247 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CaseMode controls case sensitivity of searches
+type CaseMode int
+
+const (
+	CaseSmart       CaseMode = iota // Insensitive unless the query has uppercase
+	CaseSensitive                   // Always match case
+	CaseInsensitive                 // Never match case
+)
+
+func (c CaseMode) String() string {
+	switch c {
+	case CaseSensitive:
+		return "case"
+	case CaseInsensitive:
+		return "nocase"
+	default:
+		return "smartcase"
+	}
+}
+
+// SearchScope restricts Message mode searches to one kind of content
+type SearchScope int
+
+const (
+	ScopeAll SearchScope = iota
+	ScopeUserPrompts
+	ScopeAssistantText
+	ScopeToolInputs
+	ScopeToolResults
+	ScopeThinking
+	numSearchScopes
+)
+
+func (s SearchScope) String() string {
+	switch s {
+	case ScopeUserPrompts:
+		return "prompts"
+	case ScopeAssistantText:
+		return "assistant"
+	case ScopeToolInputs:
+		return "tool-input"
+	case ScopeToolResults:
+		return "tool-result"
+	case ScopeThinking:
+		return "thinking"
+	default:
+		return "all"
+	}
+}
+
+// Includes reports whether a block of msg is searched under this scope
+func (s SearchScope) Includes(msg *Message, block ContentBlock) bool {
+	switch s {
+	case ScopeUserPrompts:
+		return isUserPrompt(msg) && (block.Type == "text" || block.Type == blockCommand)
+	case ScopeAssistantText:
+		return msg.Type == "assistant" && block.Type == "text"
+	case ScopeToolInputs:
+		return block.Type == "tool_use"
+	case ScopeToolResults:
+		return block.Type == "tool_result"
+	case ScopeThinking:
+		return block.Type == "thinking"
+	default:
+		return true
+	}
+}
+
+// SearchOptions are the toggles that change how a query is interpreted
+type SearchOptions struct {
+	Regex     bool
+	Case      CaseMode
+	WholeWord bool
+	Scope     SearchScope // Message mode only
+}
+
+// String describes the enabled options for the prompt and header
+func (o SearchOptions) String() string {
+	parts := []string{o.Case.String()}
+	if o.Regex {
+		parts = append(parts, "regex")
+	}
+	if o.WholeWord {
+		parts = append(parts, "word")
+	}
+	if o.Scope != ScopeAll {
+		parts = append(parts, "in:"+o.Scope.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// SearchPattern is a compiled search query
+type SearchPattern struct {
+	re *regexp.Regexp
+}
+
+// CompileSearch compiles query according to opts. The error is only non-nil
+// for invalid regexes.
+func CompileSearch(query string, opts SearchOptions) (*SearchPattern, error) {
+	expr := query
+	if !opts.Regex {
+		expr = regexp.QuoteMeta(query)
+	}
+	if opts.WholeWord {
+		expr = `\b(?:` + expr + `)\b`
+	}
+
+	insensitive := opts.Case == CaseInsensitive ||
+		(opts.Case == CaseSmart && !hasUpper(query))
+	if insensitive {
+		expr = "(?i)" + expr
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchPattern{re: re}, nil
+}
+
+// hasUpper reports whether s contains an uppercase letter
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAll returns the byte ranges of all non-empty matches in s
+func (p *SearchPattern) FindAll(s string) [][2]int {
+	var ranges [][2]int
+	for _, loc := range p.re.FindAllStringIndex(s, -1) {
+		if loc[1] > loc[0] {
+			ranges = append(ranges, [2]int{loc[0], loc[1]})
+		}
+	}
+	return ranges
+}
+
+// Matches reports whether s contains at least one non-empty match
+func (p *SearchPattern) Matches(s string) bool {
+	return len(p.FindAll(s)) > 0
+}
+
+// searchHitLines returns the line index of every match in lines, one entry per
+// match, so len() is the match count. ANSI codes are ignored.
+func searchHitLines(lines []string, p *SearchPattern) []int {
+	var hits []int
+	for i, line := range lines {
+		for range p.FindAll(StripAnsi(line)) {
+			hits = append(hits, i)
+		}
+	}
+	return hits
+}
+
+// nextHitLine returns the first hit line after (direction > 0) or before
+// (direction < 0) line, wrapping around. ok is false when there are no hits.
+func nextHitLine(hits []int, line, direction int) (int, bool) {
+	if len(hits) == 0 {
+		return 0, false
+	}
+	if direction > 0 {
+		i := sort.SearchInts(hits, line+1)
+		if i == len(hits) {
+			i = 0
+		}
+		return hits[i], true
+	}
+	i := sort.SearchInts(hits, line) - 1
+	if i < 0 {
+		i = len(hits) - 1
+	}
+	return hits[i], true
+}
+
+// hitPosition returns the 1-based index of the first hit on line, or 0 if
+// the line has no hit
+func hitPosition(hits []int, line int) int {
+	i := sort.SearchInts(hits, line)
+	if i < len(hits) && hits[i] == line {
+		return i + 1
+	}
+	return 0
+}
+
+// messageSearchHits searches the message data rather than the rendered
+// lines, so badges and markdown decoration never match and text that wrapped
+// in rendering still does. Each match is mapped back to the rendered line it
+// most likely landed on; the result is sorted, one entry per match.
+func messageSearchHits(messages []Message, lines []string, layout threadLayout, p *SearchPattern, scope SearchScope) []int {
+	var hits []int
+	for i := range messages {
+		msg := &messages[i]
+		for j, block := range msg.Content {
+			if !scope.Includes(msg, block) || i >= len(layout.blockStart) || layout.blockStart[i][j] < 0 {
+				continue
+			}
+			matches := p.FindAll(block.Content)
+			if len(matches) == 0 {
+				continue
+			}
+			hits = append(hits, mapBlockMatches(lines, layout, blockRef{Msg: i, Block: j}, block.Content, matches, p)...)
+		}
+	}
+	sort.Ints(hits)
+	return hits
+}
+
+// mapBlockMatches maps matches within a block's content to rendered lines.
+// The k-th match goes to the k-th match in the block's rendered lines; when
+// rendering broke a match apart, its line is estimated from its offset.
+func mapBlockMatches(lines []string, layout threadLayout, ref blockRef, content string, matches [][2]int, p *SearchPattern) []int {
+	start := layout.blockStart[ref.Msg][ref.Block]
+	end := start
+	for end < len(layout.lineBlock) && layout.lineBlock[end] == ref {
+		end++
+	}
+
+	var rendered []int
+	for line := start; line < end; line++ {
+		for range p.FindAll(StripAnsi(lines[line])) {
+			rendered = append(rendered, line)
+		}
+	}
+
+	hits := make([]int, len(matches))
+	for k, match := range matches {
+		if k < len(rendered) {
+			hits[k] = rendered[k]
+			continue
+		}
+		hits[k] = start + (end-start)*match[0]/len(content)
+	}
+	return hits
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCompileSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		opts  SearchOptions
+		input string
+		want  int
+	}{
+		{"smart case lowercase", "hello", SearchOptions{}, "Hello hello HELLO", 3},
+		{"smart case uppercase", "Hello", SearchOptions{}, "Hello hello HELLO", 1},
+		{"case sensitive", "hello", SearchOptions{Case: CaseSensitive}, "Hello hello", 1},
+		{"case insensitive", "Hello", SearchOptions{Case: CaseInsensitive}, "Hello hello", 2},
+		{"literal metacharacters", "a.b", SearchOptions{}, "a.b axb", 1},
+		{"regex", `a.b`, SearchOptions{Regex: true}, "a.b axb", 2},
+		{"whole word", "test", SearchOptions{WholeWord: true}, "test testing attest test", 2},
+		{"regex whole word", `err(or)?`, SearchOptions{Regex: true, WholeWord: true}, "err error errors", 2},
+		{"empty matches ignored", `x*`, SearchOptions{Regex: true}, "abc", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CompileSearch(tt.query, tt.opts)
+			if err != nil {
+				t.Fatalf("CompileSearch(%q) failed: %v", tt.query, err)
+			}
+			if got := len(p.FindAll(tt.input)); got != tt.want {
+				t.Errorf("FindAll(%q) found %d matches, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileSearchInvalidRegex(t *testing.T) {
+	if _, err := CompileSearch("foo(", SearchOptions{Regex: true}); err == nil {
+		t.Error("Expected an error for an invalid regex")
+	}
+	if _, err := CompileSearch("foo(", SearchOptions{}); err != nil {
+		t.Errorf("Literal search should accept any query: %v", err)
+	}
+}
+
+func TestNextHitLine(t *testing.T) {
+	hits := []int{2, 2, 5, 9}
+
+	tests := []struct {
+		line, direction, want int
+	}{
+		{0, 1, 2},
+		{2, 1, 5},
+		{9, 1, 2}, // wraps
+		{5, -1, 2},
+		{2, -1, 9}, // wraps
+	}
+	for _, tt := range tests {
+		got, ok := nextHitLine(hits, tt.line, tt.direction)
+		if !ok || got != tt.want {
+			t.Errorf("nextHitLine(%d, %d) = %d, want %d", tt.line, tt.direction, got, tt.want)
+		}
+	}
+
+	if pos := hitPosition(hits, 5); pos != 3 {
+		t.Errorf("hitPosition(5) = %d, want 3", pos)
+	}
+	if pos := hitPosition(hits, 4); pos != 0 {
+		t.Errorf("hitPosition(4) = %d, want 0", pos)
+	}
+}
+
+func TestMessageSearchHits(t *testing.T) {
+	m := Model{messages: []Message{
+		{Type: "user", Content: []ContentBlock{{Type: "text", Content: "please check the user table"}}},
+		{Type: "assistant", Content: []ContentBlock{
+			{Type: "thinking", Content: "the user wants a check"},
+			{Type: "tool_use", Name: "Bash", Content: `{"command": "psql -c 'select * from user'"}`},
+		}},
+	}}
+	m.renderThread(80)
+
+	pattern, _ := CompileSearch("user", SearchOptions{})
+
+	// Badge labels are not message content and must not match
+	all := messageSearchHits(m.messages, m.renderedThread, m.layout, pattern, ScopeAll)
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 hits across all scopes, got %d", len(all))
+	}
+	for _, line := range all {
+		if line == m.layout.msgStart[0] || line == m.layout.msgStart[1] {
+			t.Errorf("Hit on badge line %d", line)
+		}
+	}
+
+	thinking := messageSearchHits(m.messages, m.renderedThread, m.layout, pattern, ScopeThinking)
+	if len(thinking) != 1 {
+		t.Fatalf("Expected 1 thinking hit, got %d", len(thinking))
+	}
+	if ref := m.layout.lineBlock[thinking[0]]; ref.Msg != 1 || ref.Block != 0 {
+		t.Errorf("Thinking hit mapped to %+v, want message 1 block 0", ref)
+	}
+
+	tools := messageSearchHits(m.messages, m.renderedThread, m.layout, pattern, ScopeToolInputs)
+	if len(tools) != 1 || m.layout.lineBlock[tools[0]].Block != 1 {
+		t.Errorf("Expected a single tool input hit in block 1, got %v", tools)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSessionSearchHits caps the number of ranked results shown
+const maxSessionSearchHits = 200
+
+// sessionSearch is the state of the cross-session search screen
+type sessionSearch struct {
+	input     string     // Query being typed
+	typing    bool       // Keys go to the input rather than the result list
+	query     string     // Last query that was run
+	hits      []IndexHit // Ranked results
+	selected  int
+	scroll    int   // First visible hit
+	err       error // Query parse error
+	prevState State // Where leaving the screen returns to
+}
+
+// indexUpdatedMsg is sent when a background index update finishes
+type indexUpdatedMsg struct {
+	index *Index
+	err   error
+}
+
+// startIndexing kicks off a background index update unless one is running
+func (m *Model) startIndexing() tea.Cmd {
+	if m.indexPath == "" || m.indexing {
+		return nil
+	}
+	m.indexing = true
+	return updateIndexCmd(m.indexPath, m.redactor)
+}
+
+// updateIndexCmd loads the on-disk index, brings it up to date and saves it.
+// The command works on its own copy of the index, so the model's index is
+// never mutated concurrently.
+func updateIndexCmd(indexPath string, redactor *Redactor) tea.Cmd {
+	return func() tea.Msg {
+		root, err := GetClaudeProjectsDir()
+		if err != nil {
+			return indexUpdatedMsg{err: err}
+		}
+		index, err := LoadIndex(indexPath)
+		if err != nil {
+			return indexUpdatedMsg{index: index, err: err}
+		}
+		changed, err := index.Update(root, redactor)
+		if err != nil {
+			return indexUpdatedMsg{index: index, err: err}
+		}
+		if changed > 0 {
+			err = index.Save(indexPath)
+		}
+		return indexUpdatedMsg{index: index, err: err}
+	}
+}
+
+// openSessionSearch switches to the search screen and refreshes the index
+func (m *Model) openSessionSearch() tea.Cmd {
+	m.sessionSearch.prevState = m.state
+	m.sessionSearch.typing = true
+	m.state = StateSessionSearch
+	return m.startIndexing()
+}
+
+// runSessionSearch runs the typed query against the index
+func (m *Model) runSessionSearch() {
+	ss := &m.sessionSearch
+	ss.hits = nil
+	ss.selected = 0
+	ss.scroll = 0
+	ss.err = nil
+	ss.query = ss.input
+	if strings.TrimSpace(ss.input) == "" || m.index == nil {
+		return
+	}
+
+	q, err := ParseIndexQuery(ss.input)
+	if err != nil {
+		ss.err = err
+		return
+	}
+	ss.hits = m.index.Search(q, maxSessionSearchHits)
+}
+
+func (m Model) handleSessionSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ss := &m.sessionSearch
+	key := msg.String()
+
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	if ss.typing {
+		switch key {
+		case "enter":
+			ss.typing = false
+			m.runSessionSearch()
+		case "esc":
+			if ss.input == "" {
+				m.state = ss.prevState
+			}
+			ss.typing = false
+		case "backspace":
+			ss.input = trimLastRune(ss.input)
+		default:
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				ss.input += string(msg.Runes)
+			}
+		}
+		return m, nil
+	}
+
+	switch key {
+	case "q", "esc":
+		m.state = ss.prevState
+
+	case "/", "i":
+		ss.typing = true
+
+	case "j", "down":
+		if ss.selected < len(ss.hits)-1 {
+			ss.selected++
+		}
+
+	case "k", "up":
+		if ss.selected > 0 {
+			ss.selected--
+		}
+
+	case "enter":
+		if ss.selected < len(ss.hits) {
+			hit := ss.hits[ss.selected]
+			if err := m.openSession(hit.Path); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.returnState = StateSessionSearch
+			m.jumpToMessage(hit.UUID)
+		}
+	}
+
+	m.ensureHitVisible()
+	return m, nil
+}
+
+// sessionSearchPageSize is the number of hits visible at once (two lines each)
+func (m Model) sessionSearchPageSize() int {
+	n := (m.height - 6) / 2
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// ensureHitVisible keeps the selected hit on screen
+func (m *Model) ensureHitVisible() {
+	ss := &m.sessionSearch
+	page := m.sessionSearchPageSize()
+	if ss.selected < ss.scroll {
+		ss.scroll = ss.selected
+	}
+	if ss.selected >= ss.scroll+page {
+		ss.scroll = ss.selected - page + 1
+	}
+}
+
+// jumpToMessage scrolls Message mode to the message with the given UUID
+func (m *Model) jumpToMessage(uuid string) {
+	for i := range m.messages {
+		if m.messages[i].UUID == uuid && i < len(m.layout.msgStart) {
+			if m.layout.msgStart[i] < 0 {
+				m.applyFilter("") // Filtered out; show everything
+			}
+			m.threadScrollOffset = m.layout.msgStart[i]
+			m.selectMessage(i)
+			return
+		}
+	}
+}
+
+func (m Model) viewSessionSearch() string {
+	ss := m.sessionSearch
+	var b strings.Builder
+
+	// Header
+	header := titleStyle.Render("Search all sessions")
+	status := ""
+	switch {
+	case m.indexing:
+		status = "indexing…"
+	case m.indexErr != nil:
+		status = "index error: " + m.indexErr.Error()
+	case m.index != nil:
+		status = fmt.Sprintf("%d sessions indexed", len(m.index.Files))
+	}
+	status = helpStyle.Render(status)
+	headerPadding := m.width - lipgloss.Width(header) - lipgloss.Width(status)
+	if headerPadding < 1 {
+		headerPadding = 1
+	}
+	b.WriteString(header + strings.Repeat(" ", headerPadding) + status + "\n")
+
+	// Query input
+	prompt := searchStyle.Render("> " + ss.input)
+	if ss.typing {
+		prompt += searchStyle.Render("█")
+	}
+	b.WriteString(prompt + "\n")
+	b.WriteString(strings.Repeat("─", m.width) + "\n")
+
+	// Results
+	page := m.sessionSearchPageSize()
+	switch {
+	case ss.err != nil:
+		b.WriteString(warningStyle.Render(ss.err.Error()) + "\n")
+	case ss.query != "" && len(ss.hits) == 0 && !m.indexing:
+		b.WriteString(helpStyle.Render("No matches") + "\n")
+	}
+	for i := ss.scroll; i < len(ss.hits) && i < ss.scroll+page; i++ {
+		hit := ss.hits[i]
+		ts := ""
+		if !hit.Timestamp.IsZero() {
+			ts = hit.Timestamp.Local().Format("2006-01-02 15:04")
+		}
+		title := fmt.Sprintf("%s  %-9s  %s", ts, hit.Role, hit.Project)
+		title = truncateText(title, m.width-2)
+		snippet := "    " + truncateText(hit.Snippet, m.width-6)
+		if i == ss.selected {
+			b.WriteString(selectedStyle.Render("> "+title) + "\n")
+		} else {
+			b.WriteString(normalStyle.Render("  "+title) + "\n")
+		}
+		b.WriteString(helpStyle.Render(snippet) + "\n")
+	}
+
+	// Footer
+	b.WriteString("\n")
+	if ss.typing {
+		b.WriteString(helpStyle.Render("enter: search • esc: results • filters: after:YYYY-MM-DD before: project: role:user|assistant|tool"))
+	} else {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("%d hits • j/k: move • enter: open • /: edit query • q: back", len(ss.hits))))
+	}
+
+	return b.String()
+}
+
+// trimLastRune removes the last character of typed input
+func trimLastRune(s string) string {
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// recordAtLine returns the record holding a line of the full JSON content,
+// the next record when the line is a separator, or -1 past the last one
+func (m Model) recordAtLine(line int) int {
+	r := sort.Search(len(m.records), func(r int) bool { return m.records[r].End > line })
+	if r >= len(m.records) {
+		return -1
+	}
+	return r
+}
+
+// messageAtRecord returns the message parsed from record r or, for records
+// without one, the next message (the last one past the end), or -1 if there
+// are none
+func (m Model) messageAtRecord(r int) int {
+	if len(m.msgRecord) == 0 || r < 0 {
+		return -1
+	}
+	i := sort.SearchInts(m.msgRecord, r)
+	if i >= len(m.msgRecord) {
+		i = len(m.msgRecord) - 1
+	}
+	return i
+}
+
+// syncToMessage shows the message of the JSON record under the cursor at the
+// top of the thread. A message hidden by the filter or kind toggles gives way
+// to the next shown one.
+func (m *Model) syncToMessage() {
+	i := m.messageAtRecord(m.recordAtLine(m.originLine(m.cursorLine)))
+	if i < 0 || i >= len(m.layout.msgStart) {
+		return
+	}
+	line := m.nextShownLine(i)
+	m.threadScrollOffset = line
+	if shown := m.messageAtLine(line); shown >= 0 {
+		m.msgCursor = shown
+	}
+	m.handleMessageNavigation(0, 1) // Clamp
+}
+
+// syncToJSON puts the JSON cursor on the first line of the selected
+// message's record, at the top of the screen
+func (m *Model) syncToJSON() bool {
+	if m.msgCursor >= len(m.msgRecord) {
+		return false
+	}
+	rec := m.records[m.msgRecord[m.msgCursor]]
+	m.cursorLine = m.displayLine(rec.Start)
+	m.scrollOffset = m.cursorLine
+	m.handleJSONNavigation(0, 1) // Clamp
+	return true
+}
+
+// toggleViewMode switches between JSON and Message mode, keeping the same
+// record in view
+func (m *Model) toggleViewMode() {
+	if m.viewMode == ViewModeJSON {
+		m.viewMode = ViewModeMessage
+		m.syncToMessage()
+	} else {
+		m.viewMode = ViewModeJSON
+		m.syncToJSON()
+	}
+	m.refreshSearch()
+}
+
+// splitWidth returns the width of the raw JSON pane of the split layout
+func (m Model) splitWidth() int {
+	width := m.width
+	if m.sidebarShown() {
+		width -= m.outlineWidth() + 3
+	}
+	return width * 45 / 100
+}
+
+// toggleSplit shows or hides the raw JSON of the selected message next to
+// the thread
+func (m *Model) toggleSplit() {
+	m.showSplit = !m.showSplit
+	m.rerenderAnchored(blockRef{Msg: m.msgCursor, Block: -1})
+}
+
+// buildRawPane renders the highlighted JSON record of the selected message.
+// Once the message's start has scrolled off the top, the record scrolls
+// along in proportion.
+func (m Model) buildRawPane(width, height int) []string {
+	if m.msgCursor >= len(m.msgRecord) {
+		return []string{noPreviewStyle.Render("No raw record for this message")}
+	}
+	rec := m.records[m.msgRecord[m.msgCursor]]
+	lines := m.fullHighlightedLines[rec.Start:rec.End]
+
+	offset := 0
+	start, end := m.layout.msgStart[m.msgCursor], m.layout.msgEnd[m.msgCursor]
+	if start >= 0 && m.threadScrollOffset > start && end > start {
+		offset = (m.threadScrollOffset - start) * len(lines) / (end - start)
+	}
+	offset = max(0, min(offset, len(lines)-height+1)) // One row goes to the header
+
+	header := helpStyle.Render(truncateText(fmt.Sprintf("JSON lines %d–%d", rec.Start+1, rec.End), width))
+	return append([]string{header}, lines[offset:min(len(lines), offset+height-1)]...)
+}
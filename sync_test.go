@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToggleViewModeKeepsRecord(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.jsonl")
+	testData := `{"type":"user","message":{"role":"user","content":"first"},"uuid":"u-1"}
+{"type":"file-history-snapshot","messageId":"x"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"second"}]},"uuid":"a-1"}
+{"type":"user","message":{"role":"user","content":"third"},"uuid":"u-2"}`
+	if err := os.WriteFile(testFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	m := Model{width: 80, height: 40}
+	if err := m.openSession(testFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// Message → JSON lands on the selected message's record
+	m.selectMessage(2)
+	m.toggleViewMode()
+	if m.viewMode != ViewModeJSON || m.cursorLine != m.records[3].Start {
+		t.Errorf("JSON cursor on line %d, want %d", m.cursorLine, m.records[3].Start)
+	}
+
+	// JSON → Message selects the record's message, or the next one for
+	// records that aren't messages
+	m.cursorLine = m.records[2].Start + 1
+	m.toggleViewMode()
+	if m.viewMode != ViewModeMessage || m.msgCursor != 1 {
+		t.Errorf("Selected message %d, want 1", m.msgCursor)
+	}
+	m.viewMode = ViewModeJSON
+	m.cursorLine = m.records[1].Start
+	m.toggleViewMode()
+	if m.msgCursor != 1 {
+		t.Errorf("Snapshot record selected message %d, want 1", m.msgCursor)
+	}
+}
+
+func TestSplitLayout(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.jsonl")
+	testData := `{"type":"user","message":{"role":"user","content":"hello"},"uuid":"u-1"}`
+	if err := os.WriteFile(testFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	m := Model{width: 100, height: 30}
+	if err := m.openSession(testFile); err != nil {
+		t.Fatal(err)
+	}
+	full := m.threadWidth()
+	m.toggleSplit()
+	if m.threadWidth() >= full {
+		t.Errorf("Split should narrow the thread, width %d", m.threadWidth())
+	}
+	pane := m.buildRawPane(m.splitWidth(), 10)
+	if len(pane) < 2 || StripAnsi(pane[1]) != "{" {
+		t.Errorf("Raw pane should show the record, got %q", pane)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// codeStyle is the chroma style used for syntax highlighting, set by the
+// theme; "" turns highlighting off
+var codeStyle = "monokai"
+
+// maxHighlightSize is the largest text that is syntax-highlighted; bigger
+// tool output is shown plain to keep re-rendering fast
+const maxHighlightSize = 256 << 10
+
+// codeLexer picks a lexer from a file name (by extension or well-known name)
+// or, failing that, a language name. It returns nil for unknown languages.
+func codeLexer(filename, lang string) chroma.Lexer {
+	if filename != "" {
+		if lexer := lexers.Match(filepath.Base(filename)); lexer != nil {
+			return lexer
+		}
+	}
+	if lang != "" {
+		return lexers.Get(lang)
+	}
+	return nil
+}
+
+// highlightCode syntax-highlights code for the terminal using the lexer for
+// filename or lang. Each line is formatted on its own so that it keeps its
+// colours when the thread is scrolled or truncated. Code in an unknown
+// language is returned unchanged.
+func highlightCode(code, filename, lang string) string {
+	lexer := codeLexer(filename, lang)
+	if lexer == nil || codeStyle == "" || len(code) > maxHighlightSize {
+		return code
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return code
+	}
+
+	formatter := formatters.Get("terminal256")
+	style := styles.Get(codeStyle)
+	lines := chroma.SplitTokensIntoLines(iterator.Tokens())
+
+	var out []string
+	for _, tokens := range lines {
+		if n := len(tokens); n > 0 {
+			tokens[n-1].Value = strings.TrimSuffix(tokens[n-1].Value, "\n")
+		}
+		var b strings.Builder
+		if err := formatter.Format(&b, style, chroma.Literator(tokens...)); err != nil {
+			return code
+		}
+		out = append(out, b.String())
+	}
+
+	// Tokenise adds a final newline when the code lacks one
+	if !strings.HasSuffix(code, "\n") && len(out) > len(strings.Split(code, "\n")) {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+// sniffLanguage guesses the language of text that has no file name, such as
+// Bash output. It only recognises formats with unmistakable markers and
+// returns "" otherwise.
+func sniffLanguage(text string) string {
+	trimmed := strings.TrimSpace(text)
+	first := firstLine(trimmed)
+	switch {
+	case trimmed == "":
+		return ""
+	case (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)):
+		return "json"
+	case strings.HasPrefix(first, "diff --git") ||
+		strings.HasPrefix(first, "--- ") && strings.Contains(trimmed, "\n+++ ") && strings.Contains(trimmed, "\n@@ "):
+		return "diff"
+	case strings.HasPrefix(first, "#!"):
+		for _, lang := range []string{"python", "node", "ruby", "perl", "bash", "sh"} {
+			if strings.Contains(first, lang) {
+				return lang
+			}
+		}
+	case strings.HasPrefix(first, "<?xml"):
+		return "xml"
+	case strings.HasPrefix(strings.ToLower(first), "<!doctype html") || strings.HasPrefix(first, "<html"):
+		return "html"
+	case strings.HasPrefix(first, "package ") && strings.Contains(trimmed, "\nfunc "):
+		return "go"
+	case first == "---" && yamlKeyPattern.MatchString(trimmed):
+		return "yaml"
+	}
+	return ""
+}
+
+var yamlKeyPattern = regexp.MustCompile(`^---\n[A-Za-z_][\w-]*:`)
+
+// catLinePattern matches a line of `cat -n` style output, as produced by the
+// Read tool: a right-aligned line number, then a tab or an arrow
+var catLinePattern = regexp.MustCompile(`^ *(\d+)(?:\t|→)(.*)$`)
+
+// highlightToolResult syntax-highlights a tool result for the file its call
+// worked on or, failing that, for what its content looks like: code, a diff,
+// a stack trace or coloured terminal output. Line-numbered output keeps its
+// numbers, styled as a gutter. Errors and plain output are shown as they are.
+func highlightToolResult(block ContentBlock, width int) string {
+	if block.IsError {
+		return block.Content
+	}
+
+	lines := strings.Split(block.Content, "\n")
+	numbers := make([]string, len(lines))
+	var code []string
+	if block.Path != "" {
+		for i, line := range lines {
+			if m := catLinePattern.FindStringSubmatch(line); m != nil {
+				numbers[i] = m[1]
+				code = append(code, m[2])
+			}
+		}
+	}
+	if len(code) == 0 && block.Path != "" {
+		return highlightCode(block.Content, block.Path, sniffLanguage(block.Content))
+	}
+	if len(code) == 0 {
+		switch kind, lang := classifyContent(block.Content); kind {
+		case ContentCode:
+			return highlightCode(block.Content, "", lang)
+		case ContentDiff:
+			return renderDiffText(block.Content)
+		case ContentStackTrace:
+			return renderStackTrace(block.Content)
+		case ContentANSI:
+			return renderANSI(block.Content, width)
+		}
+		return block.Content // Tool output isn't markdown, and keeps its own layout
+	}
+
+	// Highlight the numbered lines as one piece of code, so constructs
+	// spanning lines are coloured right, and put the gutter back
+	text := strings.Join(code, "\n")
+	highlighted := strings.Split(highlightCode(text, block.Path, sniffLanguage(text)), "\n")
+	if len(highlighted) != len(code) {
+		highlighted = code
+	}
+	k := 0
+	for i := range lines {
+		if numbers[i] == "" {
+			continue
+		}
+		lines[i] = lineNumberStyle.Render(numbers[i]) + " " + highlighted[k]
+		k++
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// Block types for the tagged payloads Claude Code puts in user records
+const (
+	blockCommand       = "command"        // A slash command the user ran
+	blockCommandOutput = "command_output" // Output of a local command
+	blockReminder      = "reminder"       // Text injected by Claude Code, not typed by the user
+)
+
+// reminderTags are the tags of injected text, with their labels
+var reminderTags = map[string]string{
+	"system-reminder":         "System reminder",
+	"user-prompt-submit-hook": "Prompt hook output",
+}
+
+var (
+	commandStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("213")).
+			Bold(true)
+
+	commandPanelStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("240")).
+				Padding(0, 1)
+
+	reminderStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("179"))
+
+	reminderBodyStyle = lipgloss.NewStyle().
+				BorderStyle(lipgloss.NormalBorder()).
+				BorderLeft(true).
+				BorderForeground(lipgloss.Color("179")).
+				PaddingLeft(1)
+)
+
+// parseTaggedText splits user text into blocks: slash commands, command
+// output and injected reminders get blocks of their own, and the rest stays
+// text of textType. Unknown tags are left in the text.
+func parseTaggedText(text, textType string) []ContentBlock {
+	if !strings.Contains(text, "<") {
+		return []ContentBlock{{Type: textType, Content: text}}
+	}
+
+	var blocks []ContentBlock
+	var pending strings.Builder // Text not yet put in a block
+	flush := func() {
+		if s := strings.TrimSpace(pending.String()); s != "" {
+			blocks = append(blocks, ContentBlock{Type: textType, Content: s})
+		}
+		pending.Reset()
+	}
+	var command *ContentBlock // The command being assembled from its tags
+
+	for _, part := range splitTagged(text) {
+		switch part.Tag {
+		case "command-name", "command-message", "command-args":
+			if command == nil {
+				flush()
+				blocks = append(blocks, ContentBlock{Type: blockCommand})
+				command = &blocks[len(blocks)-1]
+			}
+			value := strings.TrimSpace(part.Text)
+			switch part.Tag {
+			case "command-name":
+				command.Name = value
+			case "command-message":
+				command.Note = value
+			case "command-args":
+				command.Args = value
+			}
+			continue
+
+		case "local-command-stdout", "local-command-stderr":
+			flush()
+			blocks = append(blocks, ContentBlock{
+				Type:    blockCommandOutput,
+				Content: strings.Trim(part.Text, "\n"),
+				IsError: part.Tag == "local-command-stderr",
+			})
+
+		case "":
+			if strings.TrimSpace(part.Text) == "" && command != nil {
+				continue // Whitespace between a command's tags
+			}
+			pending.WriteString(part.Text)
+
+		default:
+			if _, ok := reminderTags[part.Tag]; ok {
+				flush()
+				blocks = append(blocks, ContentBlock{
+					Type:    blockReminder,
+					Name:    part.Tag,
+					Content: strings.TrimSpace(part.Text),
+				})
+			} else {
+				pending.WriteString("<" + part.Tag + ">" + part.Text + "</" + part.Tag + ">")
+			}
+		}
+		command = nil
+	}
+	flush()
+
+	// Commands read as typed: the name, then the arguments
+	for i := range blocks {
+		if b := &blocks[i]; b.Type == blockCommand {
+			if b.Name == "" {
+				b.Name = "/" + b.Note
+			}
+			b.Content = strings.TrimSpace(b.Name + " " + b.Args)
+		}
+	}
+	return blocks
+}
+
+// renderCommand renders a slash command invocation, with its status message
+// when it says more than the name
+func renderCommand(block ContentBlock, width int) string {
+	line := commandStyle.Render("❯ "+block.Name) + " " + block.Args
+	if note := block.Note; note != "" && note != strings.TrimPrefix(block.Name, "/") {
+		line += "\n" + toolNoteStyle.Render(wordwrap.String(note, width))
+	}
+	return line
+}
+
+// renderCommandOutput renders the output of a local command in a panel
+func renderCommandOutput(block ContentBlock, width int) string {
+	label := toolNoteStyle.Render("stdout")
+	if block.IsError {
+		label = warningStyle.Render("stderr")
+	}
+	inner := max(1, width-4) // Border and padding
+	body := toolNoteStyle.Render("(no output)")
+	if strings.TrimSpace(block.Content) != "" {
+		body = renderANSI(block.Content, inner)
+	}
+	return label + "\n" + commandPanelStyle.Width(width-2).Render(body)
+}
+
+// renderReminder renders injected text set apart from what the user typed
+func renderReminder(block ContentBlock, width int) string {
+	header := reminderStyle.Render("📌 "+reminderLabel(block.Name)) + toolNoteStyle.Render("  injected, not typed by the user")
+	body := reminderBodyStyle.Render(renderText(block.Content, max(20, width-2)))
+	return header + "\n" + body
+}
+
+// reminderSummary renders a collapsed reminder as one line
+func reminderSummary(block ContentBlock, width int) string {
+	lines := strings.Count(block.Content, "\n") + 1
+	summary := fmt.Sprintf("▸ 📌 %s (%d %s)", reminderLabel(block.Name), lines, plural(lines, "line"))
+	return reminderStyle.Render(truncateText(summary, width))
+}
+
+// reminderLabel returns the label of a reminder tag
+func reminderLabel(tag string) string {
+	if label, ok := reminderTags[tag]; ok {
+		return label
+	}
+	return tag
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"os"
+
+	"github.com/charmbracelet/glamour/styles"
+)
+
+// Theme is a complete palette: a colour for every role, JSON token and
+// interface element (palette.go), with the Markdown and code styles to match.
+// An empty colour leaves the terminal's own.
+type Theme struct {
+	Colors   ColorsConfig
+	Markdown string // Glamour style
+	Code     string // Chroma style ("" leaves code unhighlighted)
+	Reverse  bool   // Mark the selection, cursor line, badges and search matches with reverse video
+	NoColor  bool   // Drop colours from tool output too
+}
+
+// themeNames are the themes the config file accepts. "auto" and
+// "high-contrast" pick their dark or light palette by the terminal's
+// background.
+var themeNames = keySet("auto", "dark", "light", "high-contrast", "no-color")
+
+// noColor is set while the no-colour theme is in use; renderers that pass
+// colours through from tool output check it
+var noColor bool
+
+// darkTheme is the palette the styles are declared with
+var darkTheme = Theme{
+	Markdown: styles.DarkStyle,
+	Code:     "monokai",
+	Colors: ColorsConfig{
+		Roles: map[string]RoleColors{
+			"user":      {"15", "27"},
+			"assistant": {"15", "34"},
+			"system":    {"0", "220"},
+			"summary":   {"15", "99"},
+			"unknown":   {"15", "240"},
+		},
+		JSON: map[string]string{"key": "81", "string": "114", "number": "141", "bool": "208", "brace": "245"},
+		UI: map[string]string{
+			"title": "205", "selected": "57", "selected_text": "229", "text": "252", "help": "241",
+			"search": "214", "status": "114", "cursor_line": "236", "line_number": "240",
+			"line_number_selected": "214", "message_cursor": "39", "mode": "39", "warning": "196",
+			"thinking": "243", "tool_use": "44", "tool_result": "214", "tool_label": "245", "tool_note": "243",
+			"border": "240", "meta": "243", "command": "213", "reminder": "179", "bookmark": "220",
+			"diff_hunk": "39", "diff_removed": "203", "diff_added": "114",
+			"diff_removed_line": "52", "diff_added_line": "22",
+			"search_match": "226", "search_match_text": "0",
+		},
+	},
+}
+
+// lightTheme darkens the dark palette for light backgrounds: grey text is
+// kept well away from white, and backgrounds are pale tints
+var lightTheme = Theme{
+	Markdown: styles.LightStyle,
+	Code:     "github",
+	Colors: ColorsConfig{
+		Roles: map[string]RoleColors{
+			"user":      {"15", "25"},
+			"assistant": {"15", "28"},
+			"system":    {"0", "178"},
+			"summary":   {"15", "91"},
+			"unknown":   {"15", "243"},
+		},
+		JSON: map[string]string{"key": "25", "string": "28", "number": "90", "bool": "130", "brace": "241"},
+		UI: map[string]string{
+			"title": "162", "selected": "153", "selected_text": "16", "text": "235", "help": "243",
+			"search": "130", "status": "28", "cursor_line": "254", "line_number": "245",
+			"line_number_selected": "130", "message_cursor": "25", "mode": "25", "warning": "160",
+			"thinking": "241", "tool_use": "30", "tool_result": "130", "tool_label": "241", "tool_note": "243",
+			"border": "249", "meta": "243", "command": "127", "reminder": "136", "bookmark": "136",
+			"diff_hunk": "25", "diff_removed": "160", "diff_added": "28",
+			"diff_removed_line": "224", "diff_added_line": "194",
+			"search_match": "220", "search_match_text": "16",
+		},
+	},
+}
+
+// highContrastDarkTheme uses bright, saturated colours and no greys on dark
+// backgrounds; dim text is told apart by style (italic thinking) instead
+var highContrastDarkTheme = Theme{
+	Markdown: styles.DarkStyle,
+	Code:     "monokai",
+	Colors: ColorsConfig{
+		Roles: map[string]RoleColors{
+			"user":      {"0", "14"},
+			"assistant": {"0", "10"},
+			"system":    {"0", "11"},
+			"summary":   {"0", "13"},
+			"unknown":   {"0", "15"},
+		},
+		JSON: map[string]string{"key": "14", "string": "10", "number": "13", "bool": "11", "brace": "15"},
+		UI: map[string]string{
+			"title": "15", "selected": "15", "selected_text": "0", "text": "15", "help": "15",
+			"search": "11", "status": "10", "cursor_line": "18", "line_number": "15",
+			"line_number_selected": "11", "message_cursor": "11", "mode": "14", "warning": "9",
+			"thinking": "15", "tool_use": "14", "tool_result": "11", "tool_label": "15", "tool_note": "15",
+			"border": "15", "meta": "15", "command": "13", "reminder": "11", "bookmark": "11",
+			"diff_hunk": "14", "diff_removed": "9", "diff_added": "10",
+			"search_match": "11", "search_match_text": "0",
+		},
+	},
+}
+
+// highContrastLightTheme is highContrastDarkTheme for light backgrounds
+var highContrastLightTheme = Theme{
+	Markdown: styles.LightStyle,
+	Code:     "github",
+	Colors: ColorsConfig{
+		Roles: map[string]RoleColors{
+			"user":      {"15", "4"},
+			"assistant": {"15", "22"},
+			"system":    {"0", "11"},
+			"summary":   {"15", "5"},
+			"unknown":   {"15", "0"},
+		},
+		JSON: map[string]string{"key": "4", "string": "22", "number": "5", "bool": "88", "brace": "0"},
+		UI: map[string]string{
+			"title": "0", "selected": "0", "selected_text": "15", "text": "0", "help": "0",
+			"search": "88", "status": "22", "cursor_line": "230", "line_number": "0",
+			"line_number_selected": "88", "message_cursor": "4", "mode": "4", "warning": "1",
+			"thinking": "0", "tool_use": "4", "tool_result": "88", "tool_label": "0", "tool_note": "0",
+			"border": "0", "meta": "0", "command": "5", "reminder": "94", "bookmark": "94",
+			"diff_hunk": "4", "diff_removed": "1", "diff_added": "22",
+			"search_match": "11", "search_match_text": "0",
+		},
+	},
+}
+
+// noColorTheme has no colours at all, for NO_COLOR (https://no-color.org).
+// Bold, italics and reverse video still mark what colour would.
+var noColorTheme = Theme{
+	Markdown: styles.NoTTYStyle,
+	Reverse:  true,
+	NoColor:  true,
+}
+
+// noColorRequested reports whether the NO_COLOR convention asks for no
+// colour: the variable is set and not empty
+func noColorRequested() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// resolveTheme returns the theme a name from the config file stands for.
+// NO_COLOR wins over the config; dark reports whether the terminal's
+// background is dark and is only asked when it matters.
+func resolveTheme(name string, dark func() bool) Theme {
+	if noColorRequested() {
+		return noColorTheme
+	}
+	switch name {
+	case "dark":
+		return darkTheme
+	case "light":
+		return lightTheme
+	case "no-color":
+		return noColorTheme
+	case "high-contrast":
+		if dark() {
+			return highContrastDarkTheme
+		}
+		return highContrastLightTheme
+	}
+	if dark() {
+		return darkTheme
+	}
+	return lightTheme
+}
+
+// applyTheme sets every colour of the palette, and the Markdown and code
+// styles, from t. Colours the theme leaves out become the terminal's own.
+func applyTheme(t Theme) {
+	for role, style := range roleStyles {
+		colors := t.Colors.Roles[role]
+		*style = style.Foreground(color(colors.Foreground)).Background(color(colors.Background)).Reverse(t.Reverse)
+	}
+	for token, style := range jsonTokenStyles {
+		*style = style.Foreground(color(t.Colors.JSON[token]))
+	}
+	for name, set := range uiColors {
+		set(t.Colors.UI[name])
+	}
+	selectedStyle = selectedStyle.Reverse(t.Reverse)
+	cursorLineStyle = cursorLineStyle.Reverse(t.Reverse)
+	searchMatchReverse = t.Reverse
+	searchHighlightStart = searchHighlightSGR()
+
+	markdownStyle = t.Markdown
+	codeStyle = t.Code
+	noColor = t.NoColor
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResolveTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	dark := func() bool { return true }
+	light := func() bool { return false }
+	tests := []struct {
+		name       string
+		background func() bool
+		want       string // Chroma style, standing in for the theme
+	}{
+		{"auto", dark, "monokai"},
+		{"auto", light, "github"},
+		{"dark", light, "monokai"},
+		{"light", dark, "github"},
+		{"no-color", dark, ""},
+	}
+	for _, tt := range tests {
+		if got := resolveTheme(tt.name, tt.background); got.Code != tt.want {
+			t.Errorf("resolveTheme(%q) has code style %q, want %q", tt.name, got.Code, tt.want)
+		}
+	}
+	if got := resolveTheme("high-contrast", light); got.Colors.UI["text"] != "0" {
+		t.Errorf("High contrast on a light background should have black text, got %q", got.Colors.UI["text"])
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if got := resolveTheme("dark", dark); !got.NoColor {
+		t.Error("NO_COLOR should win over the configured theme")
+	}
+}
+
+func TestThemesAreComplete(t *testing.T) {
+	for name, theme := range map[string]Theme{
+		"dark": darkTheme, "light": lightTheme,
+		"high-contrast dark": highContrastDarkTheme, "high-contrast light": highContrastLightTheme,
+	} {
+		if err := theme.Colors.validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		for role := range roleStyles {
+			if c := theme.Colors.Roles[role]; c.Foreground == "" || c.Background == "" {
+				t.Errorf("%s: no colours for the %s badge", name, role)
+			}
+		}
+		for token := range jsonTokenStyles {
+			if theme.Colors.JSON[token] == "" {
+				t.Errorf("%s: no colour for JSON %s", name, token)
+			}
+		}
+		for element := range uiColors {
+			if _, ok := theme.Colors.UI[element]; !ok && !strings.HasSuffix(element, "_line") {
+				t.Errorf("%s: no colour for %s", name, element)
+			}
+		}
+	}
+
+	// The dark theme is what the styles are declared with
+	if got := userBadgeStyle.GetBackground(); got != lipgloss.Color(darkTheme.Colors.Roles["user"].Background) {
+		t.Errorf("Dark theme user badge differs from the declared style: %v", got)
+	}
+	if got := keyStyle.GetForeground(); got != lipgloss.Color(darkTheme.Colors.JSON["key"]) {
+		t.Errorf("Dark theme JSON keys differ from the declared style: %v", got)
+	}
+}
+
+func TestNoColorTheme(t *testing.T) {
+	markdown := markdownStyle
+	applyTheme(noColorTheme)
+	t.Cleanup(func() {
+		applyTheme(darkTheme)
+		markdownStyle = markdown
+	})
+
+	if got := renderANSI("\x1b[31mred\x1b[0m", 80); strings.Contains(got, "31m") {
+		t.Errorf("Tool output colours should be dropped: %q", got)
+	}
+	if code := "package main"; highlightCode(code, "main.go", "") != code {
+		t.Error("Code should not be highlighted")
+	}
+	if searchHighlightStart != "\x1b[1;7m" {
+		t.Errorf("Search matches should be bold reverse video, got %q", searchHighlightStart)
+	}
+	if diffAddedBackground != "" || !selectedStyle.GetReverse() {
+		t.Error("Diff lines should have no background and the selection reverse video")
+	}
+	if _, ok := userBadgeStyle.GetBackground().(lipgloss.NoColor); !ok {
+		t.Error("Badges should have no background colour")
+	}
+}
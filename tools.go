@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+var (
+	toolLabelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("245"))
+
+	toolValueStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("252")).
+			Bold(true)
+
+	toolNoteStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("243")).
+			Italic(true)
+)
+
+// toolRenderer renders the input of a tool_use block. It returns false when
+// the input lacks what the renderer needs, and the JSON view is used instead.
+type toolRenderer func(input toolInput, width int) (string, bool)
+
+// toolRenderers maps tool names to their renderers. Tools without one,
+// including MCP tools, are shown as pretty-printed JSON.
+var toolRenderers = map[string]toolRenderer{
+	"Bash":      renderBashTool,
+	"Read":      renderReadTool,
+	"Write":     renderWriteTool,
+	"Grep":      renderGrepTool,
+	"Glob":      renderGlobTool,
+	"WebFetch":  renderWebFetchTool,
+	"WebSearch": renderWebSearchTool,
+	"Task":      renderTaskTool,
+	"Edit":      renderEditTool,
+	"MultiEdit": renderEditTool,
+}
+
+// renderToolUse renders a tool_use block with its tool's renderer, falling
+// back to the input JSON
+func renderToolUse(block ContentBlock, width int) string {
+	header := toolUseHeaderStyle.Render("🔧 " + block.Name)
+	if render, ok := toolRenderers[block.Name]; ok {
+		var input toolInput
+		if json.Unmarshal([]byte(block.Content), &input) == nil {
+			if body, ok := render(input, width); ok {
+				return header + "\n" + body
+			}
+		}
+	}
+	return header + "\n" + block.Content
+}
+
+// toolInput is a decoded tool_use input
+type toolInput map[string]interface{}
+
+// str returns a string argument, or "" if it is missing or not a string
+func (in toolInput) str(key string) string {
+	s, _ := in[key].(string)
+	return s
+}
+
+// num returns a numeric argument
+func (in toolInput) num(key string) (float64, bool) {
+	n, ok := in[key].(float64)
+	return n, ok
+}
+
+// flag returns a boolean argument
+func (in toolInput) flag(key string) bool {
+	b, _ := in[key].(bool)
+	return b
+}
+
+// toolField renders a "label: value" line
+func toolField(label, value string) string {
+	return toolLabelStyle.Render(label+": ") + toolValueStyle.Render(value)
+}
+
+func renderBashTool(in toolInput, width int) (string, bool) {
+	command := in.str("command")
+	if command == "" {
+		return "", false
+	}
+	var lines []string
+	if desc := in.str("description"); desc != "" {
+		lines = append(lines, toolNoteStyle.Render(wordwrap.String(desc, width)))
+	}
+	lines = append(lines, toolLabelStyle.Render("$ ")+highlightCode(command, "", "bash"))
+
+	var notes []string
+	if ms, ok := in.num("timeout"); ok {
+		notes = append(notes, "timeout "+formatMillis(ms))
+	}
+	if in.flag("run_in_background") {
+		notes = append(notes, "in background")
+	}
+	if len(notes) > 0 {
+		lines = append(lines, toolNoteStyle.Render(strings.Join(notes, " • ")))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// formatMillis renders a millisecond duration compactly, e.g. "2m" or "90s"
+func formatMillis(ms float64) string {
+	seconds := int(ms / 1000)
+	if seconds >= 60 && seconds%60 == 0 {
+		return fmt.Sprintf("%dm", seconds/60)
+	}
+	if seconds > 0 {
+		return fmt.Sprintf("%ds", seconds)
+	}
+	return fmt.Sprintf("%dms", int(ms))
+}
+
+func renderReadTool(in toolInput, width int) (string, bool) {
+	path := in.str("file_path")
+	if path == "" {
+		return "", false
+	}
+	lines := []string{toolValueStyle.Render(path)}
+
+	offset, hasOffset := in.num("offset")
+	limit, hasLimit := in.num("limit")
+	switch {
+	case hasOffset && hasLimit:
+		lines = append(lines, toolField("lines", fmt.Sprintf("%d–%d", int(offset), int(offset+limit)-1)))
+	case hasOffset:
+		lines = append(lines, toolField("lines", fmt.Sprintf("%d–end", int(offset))))
+	case hasLimit:
+		lines = append(lines, toolField("lines", fmt.Sprintf("1–%d", int(limit))))
+	}
+	if pages := in.str("pages"); pages != "" {
+		lines = append(lines, toolField("pages", pages))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func renderWriteTool(in toolInput, width int) (string, bool) {
+	path := in.str("file_path")
+	content, ok := in["content"].(string)
+	if path == "" || !ok {
+		return "", false
+	}
+	count := strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1
+	header := toolValueStyle.Render(path) + toolNoteStyle.Render(fmt.Sprintf("  (%d lines)", count))
+	return header + "\n" + highlightCode(content, path, sniffLanguage(content)), true
+}
+
+func renderGrepTool(in toolInput, width int) (string, bool) {
+	pattern := in.str("pattern")
+	if pattern == "" {
+		return "", false
+	}
+	lines := []string{toolField("pattern", pattern)}
+	if path := in.str("path"); path != "" {
+		lines = append(lines, toolField("in", path))
+	}
+
+	var opts []string
+	if glob := in.str("glob"); glob != "" {
+		opts = append(opts, "glob "+glob)
+	}
+	if typ := in.str("type"); typ != "" {
+		opts = append(opts, "type "+typ)
+	}
+	if mode := in.str("output_mode"); mode != "" {
+		opts = append(opts, mode)
+	}
+	if in.flag("-i") {
+		opts = append(opts, "ignore case")
+	}
+	if in.flag("multiline") {
+		opts = append(opts, "multiline")
+	}
+	if len(opts) > 0 {
+		lines = append(lines, toolNoteStyle.Render(strings.Join(opts, " • ")))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func renderGlobTool(in toolInput, width int) (string, bool) {
+	pattern := in.str("pattern")
+	if pattern == "" {
+		return "", false
+	}
+	lines := []string{toolField("pattern", pattern)}
+	if path := in.str("path"); path != "" {
+		lines = append(lines, toolField("in", path))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func renderWebFetchTool(in toolInput, width int) (string, bool) {
+	url := in.str("url")
+	if url == "" {
+		return "", false
+	}
+	lines := []string{toolValueStyle.Render(url)}
+	if prompt := in.str("prompt"); prompt != "" {
+		lines = append(lines, toolNoteStyle.Render(wordwrap.String(prompt, width)))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func renderWebSearchTool(in toolInput, width int) (string, bool) {
+	query := in.str("query")
+	if query == "" {
+		return "", false
+	}
+	lines := []string{toolField("query", query)}
+	for _, key := range []string{"allowed_domains", "blocked_domains"} {
+		if domains, ok := in[key].([]interface{}); ok && len(domains) > 0 {
+			var names []string
+			for _, d := range domains {
+				names = append(names, fmt.Sprint(d))
+			}
+			label := strings.ReplaceAll(key, "_", " ")
+			lines = append(lines, toolNoteStyle.Render(label+": "+strings.Join(names, ", ")))
+		}
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func renderTaskTool(in toolInput, width int) (string, bool) {
+	prompt := in.str("prompt")
+	if prompt == "" {
+		return "", false
+	}
+	agent := in.str("subagent_type")
+	if agent == "" {
+		agent = "general-purpose"
+	}
+	header := toolField("agent", agent)
+	if desc := in.str("description"); desc != "" {
+		header += toolNoteStyle.Render("  " + desc)
+	}
+	return header + "\n" + renderText(prompt, width), true
+}
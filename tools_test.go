@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderToolUse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // Substrings of the plain rendering
+	}{
+		{"Bash", `{"command": "npm test", "description": "Run the tests", "timeout": 120000}`,
+			[]string{"Run the tests", "$ npm test", "timeout 2m"}},
+		{"Read", `{"file_path": "/src/main.go", "offset": 10, "limit": 50}`,
+			[]string{"/src/main.go", "lines: 10–59"}},
+		{"Write", `{"file_path": "/src/hello.py", "content": "print('hi')\nprint('bye')\n"}`,
+			[]string{"/src/hello.py  (2 lines)", "print('hi')"}},
+		{"Grep", `{"pattern": "func main", "path": "/src", "glob": "*.go", "-i": true}`,
+			[]string{"pattern: func main", "in: /src", "glob *.go • ignore case"}},
+		{"Glob", `{"pattern": "**/*.ts"}`, []string{"pattern: **/*.ts"}},
+		{"WebFetch", `{"url": "https://example.com", "prompt": "Summarize"}`,
+			[]string{"https://example.com", "Summarize"}},
+		{"WebSearch", `{"query": "bubbletea", "allowed_domains": ["github.com"]}`,
+			[]string{"query: bubbletea", "allowed domains: github.com"}},
+		{"Task", `{"subagent_type": "Explore", "description": "Find tests", "prompt": "Look for test files"}`,
+			[]string{"agent: Explore  Find tests", "Look for test files"}},
+		{"mcp__github__get_issue", `{"issue": 7}`, []string{`"issue": 7`}},
+		{"Bash", `{"cmd": "missing command field"}`, []string{`"cmd": "missing command field"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StripAnsi(renderToolUse(ContentBlock{Type: "tool_use", Name: tt.name, Content: tt.input}, 80))
+			if !strings.HasPrefix(got, "🔧 "+tt.name+"\n") {
+				t.Errorf("missing tool header:\n%s", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("rendering lacks %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	code := "package main\n\nfunc main() {}"
+	got := highlightCode(code, "main.go", "")
+	if got == code {
+		t.Error("Go code should be highlighted")
+	}
+	if StripAnsi(got) != code {
+		t.Errorf("Highlighting changed the text:\n%q", StripAnsi(got))
+	}
+	if got := highlightCode("plain", "notes.unknownext", ""); got != "plain" {
+		t.Errorf("Unknown languages should be unchanged, got %q", got)
+	}
+}
+
+func TestSniffLanguage(t *testing.T) {
+	tests := map[string]string{
+		`{"a": 1}`:                                "json",
+		"diff --git a/x b/x\n--- a/x\n+++ b/x":    "diff",
+		"--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b":   "diff",
+		"#!/usr/bin/env python3\nprint(1)":        "python",
+		"#!/bin/sh\necho hi":                      "sh",
+		"<?xml version=\"1.0\"?>\n<a/>":           "xml",
+		"<!DOCTYPE html>\n<html></html>":          "html",
+		"package main\n\nfunc main() {}":          "go",
+		"---\nname: test\n":                       "yaml",
+		"total 8\ndrwxr-xr-x  2 user staff  64 x": "",
+		"{not json": "",
+		"":          "",
+	}
+	for text, want := range tests {
+		if got := sniffLanguage(text); got != want {
+			t.Errorf("sniffLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestHighlightToolResult(t *testing.T) {
+	content := "     1\tpackage main\n     2\t\n     3→func main() {}\n\n<system-reminder>note</system-reminder>"
+	got := highlightToolResult(ContentBlock{Type: "tool_result", Content: content, Tool: "Read", Path: "/src/main.go"}, 80)
+	if got == content {
+		t.Fatal("Read output should be highlighted")
+	}
+	lines := strings.Split(StripAnsi(got), "\n")
+	want := []string{"     1 package main", "     2 ", "     3 func main() {}", "", "<system-reminder>note</system-reminder>"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Gutter not kept:\n%q", lines)
+	}
+
+	if got := highlightToolResult(ContentBlock{Type: "tool_result", Content: content, Path: "/src/main.go", IsError: true}, 80); got != content {
+		t.Error("Errors should not be highlighted")
+	}
+	plain := "     1\tnot a file"
+	if got := highlightToolResult(ContentBlock{Type: "tool_result", Content: plain, Tool: "Bash"}, 80); got != plain {
+		t.Errorf("Numbered output without a file should be unchanged, got %q", got)
+	}
+}